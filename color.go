@@ -0,0 +1,76 @@
+package pprint
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Theme holds the ANSI escape sequences used to color each kind of token.
+// Tokens whose sequence is empty are left uncolored.
+type Theme struct {
+	Key     string // map keys and struct field names
+	String  string
+	Number  string
+	Bool    string // true, false and nil
+	Type    string // struct, pointer and named scalar type names
+	Address string // pointer addresses
+	Marker  string // recursion markers, depth placeholders and inaccessible fields
+}
+
+// DarkTheme uses bright colors that read well on a dark background.
+var DarkTheme = Theme{
+	Key:     "\x1b[94m",
+	String:  "\x1b[92m",
+	Number:  "\x1b[96m",
+	Bool:    "\x1b[95m",
+	Type:    "\x1b[93m",
+	Address: "\x1b[90m",
+	Marker:  "\x1b[91m",
+}
+
+// LightTheme uses darker colors that read well on a light background.
+var LightTheme = Theme{
+	Key:     "\x1b[34m",
+	String:  "\x1b[32m",
+	Number:  "\x1b[36m",
+	Bool:    "\x1b[35m",
+	Type:    "\x1b[33m",
+	Address: "\x1b[90m",
+	Marker:  "\x1b[31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// paint wraps text in color, unless color is empty.
+func paint(color, text string) string {
+	if color == "" || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// paintKey colors a map key or field name, replacing the colors of its own
+// tokens when the theme has a key color.
+func (pp *PrettyPrinter) paintKey(rep string) string {
+	if pp.theme.Key == "" {
+		return rep
+	}
+	return paint(pp.theme.Key, stripANSI(rep))
+}
+
+// stripANSI removes color escape sequences from text.
+func stripANSI(text string) string {
+	if !strings.Contains(text, "\x1b") {
+		return text
+	}
+	return ansiEscape.ReplaceAllString(text, "")
+}
+
+// displayWidth returns the number of columns text takes up on a terminal,
+// which does not include escape sequences.
+func displayWidth(text string) int {
+	return utf8.RuneCountInString(stripANSI(text))
+}
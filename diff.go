@@ -0,0 +1,362 @@
+package pprint
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeKind tells how a value differs between the two sides of a diff.
+type ChangeKind int
+
+const (
+	Modified ChangeKind = iota // the value was replaced
+	Added                      // the value is only in the second value
+	Removed                    // the value is only in the first value
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return "modified"
+}
+
+// Change is a difference between two values, found at Path, such as
+// .Users[3].Name, under the first value for removals and under the second
+// one otherwise. Values hidden by a Redaction or a secret field tag are
+// replaced with their redaction marker.
+type Change struct {
+	Path   string
+	Kind   ChangeKind
+	Before any // nil when added
+	After  any // nil when removed
+
+	redacted bool
+}
+
+// maxAlignment bounds the number of element pairs compared to align two
+// slices; longer slices are compared index by index.
+const maxAlignment = 1 << 22
+
+// Changes returns the differences between a and b. Maps are compared by key,
+// slices and arrays by aligning their common elements, structs by field as
+// the printer shows them and pointers by the values they point to.
+func (pp *PrettyPrinter) Changes(a, b any) []Change {
+	d := &differ{pp: pp.With(Colors(Theme{})), visited: make(map[[2]uintptr]bool)}
+	d.compare(reflect.ValueOf(a), reflect.ValueOf(b), false)
+	return d.changes
+}
+
+// Diff returns the differences between a and b in unified form: the path of
+// each change followed by the value it had, marked with -, and the value it
+// has, marked with +, laid out to fit in the width.
+func (pp *PrettyPrinter) Diff(a, b any) string {
+	return pp.FormatChanges(pp.Changes(a, b))
+}
+
+// FormatChanges renders changes the way Diff does.
+func (pp *PrettyPrinter) FormatChanges(changes []Change) string {
+	values := pp.With(Width(max(pp.width-2, 1)))
+
+	var sb strings.Builder
+	side := func(marker string, object any, redacted bool) {
+		text := values.PFormat(object)
+		if marker, ok := object.(string); redacted && ok {
+			text = paint(pp.theme.Marker, marker)
+		}
+		for _, line := range strings.Split(text, "\n") {
+			sb.WriteString(marker + " " + line + "\n")
+		}
+	}
+	for _, change := range changes {
+		sb.WriteString("@@ " + change.Path + " @@\n")
+		if change.Kind != Added {
+			side("-", change.Before, change.redacted)
+		}
+		if change.Kind != Removed {
+			side("+", change.After, change.redacted)
+		}
+	}
+	return sb.String()
+}
+
+// differ walks two values side by side, collecting their differences.
+type differ struct {
+	pp      *PrettyPrinter
+	path    []string
+	visited map[[2]uintptr]bool // pairs of pointers, maps and slices being compared
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, before, after reflect.Value, redacted bool) {
+	change := Change{
+		Path:     formatPath(d.path),
+		Kind:     kind,
+		Before:   valueOrNil(before),
+		After:    valueOrNil(after),
+		redacted: redacted,
+	}
+	if redacted {
+		change.Before, change.After = d.redacted(before), d.redacted(after)
+	}
+	d.changes = append(d.changes, change)
+}
+
+// redacted returns the marker that replaces a redacted value in a change, or
+// nil for a missing one.
+func (d *differ) redacted(value reflect.Value) any {
+	if !value.IsValid() {
+		return nil
+	}
+	return d.pp.redaction.marker(value)
+}
+
+// compare records the differences between a and b at the current path.
+func (d *differ) compare(a, b reflect.Value, redacted bool) {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if a.IsValid() || b.IsValid() {
+			d.add(Modified, a, b, redacted || d.pp.redaction.value(a) || d.pp.redaction.value(b))
+		}
+		return
+	}
+	if redacted || d.pp.redaction.value(a) {
+		// Redacted values are compared whole and never shown
+		if !reflect.DeepEqual(valueOrNil(a), valueOrNil(b)) {
+			d.add(Modified, a, b, true)
+		}
+		return
+	}
+
+	// Pairs of references already being compared are equal unless shown
+	// otherwise
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !a.IsNil() && !b.IsNil() {
+			pair := [2]uintptr{valueId(a), valueId(b)}
+			if d.visited[pair] {
+				return
+			}
+			d.visited[pair] = true
+			defer delete(d.visited, pair)
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() || a.Pointer() == b.Pointer() {
+			if a.IsNil() != b.IsNil() {
+				d.add(Modified, a, b, false)
+			}
+			return
+		}
+		d.compare(a.Elem(), b.Elem(), false)
+	case reflect.Struct:
+		d.compareStructs(a, b)
+	case reflect.Map:
+		d.compareMaps(a, b)
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && (a.IsNil() != b.IsNil()) {
+			d.add(Modified, a, b, false)
+			return
+		}
+		if a.Type().Elem().Kind() == reflect.Uint8 && a.Kind() == reflect.Slice {
+			// Bytes are compared whole
+			if !bytes.Equal(a.Bytes(), b.Bytes()) {
+				d.add(Modified, a, b, false)
+			}
+			return
+		}
+		d.compareSlices(a, b)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			d.add(Modified, a, b, false)
+		}
+	default:
+		if !reflect.DeepEqual(valueOrNil(a), valueOrNil(b)) {
+			d.add(Modified, a, b, false)
+		}
+	}
+}
+
+func (d *differ) compareStructs(a, b reflect.Value) {
+	tags := structTags(a.Type())
+	before := structItems(a, d.pp.unexported)
+	after := structItems(b, d.pp.unexported)
+	for i := range before {
+		if tags[i].skip {
+			continue
+		}
+		redacted := tags[i].hint == "secret" || d.pp.redaction.field(before[i].Name) || d.pp.redaction.field(tags[i].name)
+		d.path = append(d.path, "."+before[i].Name)
+		d.compare(reflect.ValueOf(before[i].Entry), reflect.ValueOf(after[i].Entry), redacted)
+		d.path = d.path[:len(d.path)-1]
+	}
+}
+
+func (d *differ) compareMaps(a, b reflect.Value) {
+	if a.IsNil() != b.IsNil() {
+		d.add(Modified, a, b, false)
+		return
+	}
+
+	// Entries of both maps by key, in a stable order. Keys that are not
+	// equal to themselves, such as NaN, cannot be looked up and are paired in
+	// the order of their values instead.
+	var pairs, unequalA, unequalB []mapPair
+	for _, entry := range mapEntries(a, false) {
+		if !entry.key.Equal(entry.key) {
+			unequalA = append(unequalA, mapPair{key: entry.key, before: entry.value})
+			continue
+		}
+		pairs = append(pairs, mapPair{key: entry.key, before: entry.value, after: b.MapIndex(entry.key)})
+	}
+	for _, entry := range mapEntries(b, false) {
+		if !entry.key.Equal(entry.key) {
+			unequalB = append(unequalB, mapPair{key: entry.key, after: entry.value})
+		} else if !a.MapIndex(entry.key).IsValid() {
+			pairs = append(pairs, mapPair{key: entry.key, after: entry.value})
+		}
+	}
+	sortPairs(unequalA, func(p mapPair) reflect.Value { return p.before })
+	sortPairs(unequalB, func(p mapPair) reflect.Value { return p.after })
+	for i := 0; i < len(unequalA) || i < len(unequalB); i++ {
+		switch {
+		case i >= len(unequalA):
+			pairs = append(pairs, unequalB[i])
+		case i >= len(unequalB):
+			pairs = append(pairs, unequalA[i])
+		default:
+			pairs = append(pairs, mapPair{key: unequalA[i].key, before: unequalA[i].before, after: unequalB[i].after})
+		}
+	}
+	sortPairs(pairs, func(p mapPair) reflect.Value { return p.key })
+
+	for _, pair := range pairs {
+		redacted := d.pp.redaction.key(pair.key)
+		d.path = append(d.path, "["+stripANSI(flatString(d.pp.build(pair.key.Interface(), make(Context), 1)))+"]")
+		switch {
+		case !pair.before.IsValid():
+			d.add(Added, pair.before, pair.after, redacted)
+		case !pair.after.IsValid():
+			d.add(Removed, pair.before, pair.after, redacted)
+		default:
+			d.compare(pair.before, pair.after, redacted)
+		}
+		d.path = d.path[:len(d.path)-1]
+	}
+}
+
+// mapPair is a key with its values in the two maps compared, invalid where
+// the key is missing.
+type mapPair struct {
+	key, before, after reflect.Value
+}
+
+// sortPairs sorts pairs stably in the order compareValues defines for the
+// values that by returns.
+func sortPairs(pairs []mapPair, by func(mapPair) reflect.Value) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return compareValues(by(pairs[i]), by(pairs[j])) < 0
+	})
+}
+
+// compareSlices aligns the elements of a and b on their longest common
+// subsequence. Elements in between that were replaced are compared in
+// pairs, the rest are removed or added.
+func (d *differ) compareSlices(a, b reflect.Value) {
+	n, m := a.Len(), b.Len()
+	equal := func(i, j int) bool {
+		return reflect.DeepEqual(valueOrNil(a.Index(i)), valueOrNil(b.Index(j)))
+	}
+
+	// Common ends need no alignment
+	start := 0
+	for start < n && start < m && equal(start, start) {
+		start++
+	}
+	end := 0
+	for end < n-start && end < m-start && equal(n-1-end, m-1-end) {
+		end++
+	}
+
+	pairs := [][2]int{}
+	if (n-start-end)*(m-start-end) <= maxAlignment {
+		pairs = commonSubsequence(start, n-end, start, m-end, equal)
+	}
+	pairs = append(pairs, [2]int{n - end, m - end})
+
+	i, j := start, start
+	for _, pair := range pairs {
+		// Elements between matches are replaced in pairs
+		for ; i < pair[0] && j < pair[1]; i, j = i+1, j+1 {
+			d.path = append(d.path, fmt.Sprintf("[%d]", j))
+			d.compare(a.Index(i), b.Index(j), false)
+			d.path = d.path[:len(d.path)-1]
+		}
+		for ; i < pair[0]; i++ {
+			d.path = append(d.path, fmt.Sprintf("[%d]", i))
+			d.add(Removed, a.Index(i), reflect.Value{}, false)
+			d.path = d.path[:len(d.path)-1]
+		}
+		for ; j < pair[1]; j++ {
+			d.path = append(d.path, fmt.Sprintf("[%d]", j))
+			d.add(Added, reflect.Value{}, b.Index(j), false)
+			d.path = d.path[:len(d.path)-1]
+		}
+		i, j = i+1, j+1
+	}
+}
+
+// commonSubsequence returns the index pairs of a longest common subsequence
+// of the elements a[i0:i1] and b[j0:j1].
+func commonSubsequence(i0, i1, j0, j1 int, equal func(i, j int) bool) [][2]int {
+	n, m := i1-i0, j1-j0
+	// lengths[i][j] is the length of the subsequence of a[i0+i:] and b[j0+j:]
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i0+i, j0+j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	pairs := [][2]int{}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(i0+i, j0+j):
+			pairs = append(pairs, [2]int{i0 + i, j0 + j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// valueOrNil returns the value held by value, or nil if it is not valid.
+func valueOrNil(value reflect.Value) any {
+	if !value.IsValid() {
+		return nil
+	}
+	return value.Interface()
+}
@@ -0,0 +1,122 @@
+package pprint
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+type diffUser struct {
+	Name  string
+	Roles []string
+	Token string
+}
+
+type diffTeam struct {
+	Lead  *diffUser
+	Users []diffUser
+	Meta  map[string]any
+	Self  *diffTeam
+}
+
+func diffTeams() (*diffTeam, *diffTeam) {
+	users := []diffUser{{Name: "ann"}, {Name: "bob"}, {Name: "cid"}, {Name: "dan", Roles: []string{"admin", "dev"}}}
+	a := &diffTeam{Lead: &users[0], Users: users, Meta: map[string]any{"size": 4, "zone": "eu"}}
+	a.Self = a
+
+	moved := []diffUser{{Name: "ann"}, {Name: "cid"}, {Name: "dan", Roles: []string{"admin", "ops"}}, {Name: "eve"}}
+	b := &diffTeam{Lead: &diffUser{Name: "ann", Token: "t"}, Users: moved, Meta: map[string]any{"size": 4, "owner": "ann"}}
+	b.Self = b
+	return a, b
+}
+
+func TestChanges(t *testing.T) {
+	a, b := diffTeams()
+	changes := Changes(a, b, Redact(Redaction{Fields: regexp.MustCompile(`Token`)}))
+
+	exp := []Change{
+		{Path: ".Lead.Token", Kind: Modified, Before: "<redacted>", After: "<redacted>", redacted: true},
+		{Path: ".Users[1]", Kind: Removed, Before: diffUser{Name: "bob"}},
+		{Path: ".Users[2].Roles[1]", Kind: Modified, Before: "dev", After: "ops"},
+		{Path: ".Users[3]", Kind: Added, After: diffUser{Name: "eve"}},
+		{Path: `.Meta["owner"]`, Kind: Added, After: "ann"},
+		{Path: `.Meta["zone"]`, Kind: Removed, Before: "eu"},
+	}
+	if !reflect.DeepEqual(changes, exp) {
+		t.Errorf("expected %+v, got %+v", exp, changes)
+	}
+
+	if changes := Changes(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+	// NaN keys are paired by their values
+	nan := math.NaN()
+	before := map[float64]int{nan: 1, nan: 5, 0.5: 2}
+	after := map[float64]int{nan: 3, nan: 5, 0.5: 2}
+	exp = []Change{{Path: ".[NaN]", Kind: Modified, Before: 1, After: 3}}
+	if changes := Changes(before, after); !reflect.DeepEqual(changes, exp) {
+		t.Errorf("expected %+v, got %+v", exp, changes)
+	}
+	if changes := Changes(before, before); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	// Maps and slices that contain themselves are compared once
+	m := map[string]any{}
+	m["self"] = m
+	m2 := map[string]any{"x": 1}
+	m2["self"] = m2
+	if changes := Changes(m, m2); len(changes) != 1 || changes[0].Path != `.["x"]` || changes[0].Kind != Added {
+		t.Errorf("expected .[\"x\"] to be added, got %+v", changes)
+	}
+	s := []any{1, nil}
+	s[1] = s
+	s2 := []any{2, nil}
+	s2[1] = s2
+	exp = []Change{{Path: ".[0]", Kind: Modified, Before: 1, After: 2}}
+	if changes := Changes(s, s2); !reflect.DeepEqual(changes, exp) {
+		t.Errorf("expected %+v, got %+v", exp, changes)
+	}
+
+	exp = []Change{{Path: ".", Kind: Modified, Before: 1, After: "1"}}
+	if changes := Changes(1, "1"); !reflect.DeepEqual(changes, exp) {
+		t.Errorf("expected %+v, got %+v", exp, changes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, b := diffTeams()
+	exp := `@@ .Lead.Token @@
+- <redacted>
++ <redacted>
+@@ .Users[1] @@
+- diffUser{Name: "bob", Roles: [], Token: <redacted>}
+@@ .Users[2].Roles[1] @@
+- "dev"
++ "ops"
+@@ .Users[3] @@
++ diffUser{Name: "eve", Roles: [], Token: <redacted>}
+@@ .Meta["owner"] @@
++ "ann"
+@@ .Meta["zone"] @@
+- "eu"
+`
+	if out := Diff(a, b, Redact(Redaction{Fields: regexp.MustCompile(`Token`)})); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Values that do not fit are laid out on several lines, each marked
+	exp = `@@ . @@
+- {"alpha": 1,
+-  "beta": 2}
++ [1, 2]
+`
+	if out := Diff(map[string]int{"alpha": 1, "beta": 2}, []int{1, 2}, Width(20), SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	if out := Diff([]int{1, 2, 3}, []int{1, 2, 3}); out != "" {
+		t.Errorf("expected no diff, got %s", out)
+	}
+}
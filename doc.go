@@ -0,0 +1,308 @@
+package pprint
+
+import (
+	"strings"
+)
+
+// Doc is a document describing the layout of a value, in the style of
+// Wadler and Oppen. A document is built once and then laid out in a single
+// pass: each Group is printed flat, on one line, when it fits in the
+// remaining width, and broken otherwise.
+//
+// Documents are built with Text, Line, SoftLine, Concat, Join, Nest, Align,
+// Group, IfBreak and Fill, and laid out with PrettyPrinter.FormatDoc.
+type Doc struct {
+	kind  docKind
+	text  string // text, flat form of line and wrap
+	width int    // display width of text
+	n     int    // nest
+	docs  []*Doc // concat, fill items; broken form of ifBreak
+	sep   *Doc   // fill separator
+	flat  *Doc   // flat form of ifBreak
+	wrap  func(column, trailing int) *Doc
+}
+
+type docKind int
+
+const (
+	docText    docKind = iota
+	docLine            // newline and indentation when broken, text when flat
+	docRawLine         // newline without indentation when broken
+	docConcat
+	docNest  // indents lines by n more columns
+	docAlign // indents lines to the column where it starts
+	docGroup // flat when it fits, broken otherwise
+	docIfBreak
+	docFill // breaks separators only before items that do not fit
+	docWrap // flat text, or a document built for the column when broken
+)
+
+// Text is a string printed as is. It should not contain newlines; its width
+// does not count color escape sequences.
+func Text(s string) *Doc {
+	return &Doc{kind: docText, text: s, width: displayWidth(s)}
+}
+
+// Line is a space in a flat group and a newline followed by the current
+// indentation in a broken one.
+func Line() *Doc {
+	return &Doc{kind: docLine, text: " ", width: 1}
+}
+
+// SoftLine is empty in a flat group and a newline followed by the current
+// indentation in a broken one.
+func SoftLine() *Doc {
+	return &Doc{kind: docLine}
+}
+
+// rawLine is a newline without indentation in a broken group.
+func rawLine() *Doc {
+	return &Doc{kind: docRawLine}
+}
+
+// Concat prints docs one after the other.
+func Concat(docs ...*Doc) *Doc {
+	return &Doc{kind: docConcat, docs: docs}
+}
+
+// Nest indents the lines broken inside d by n more columns.
+func Nest(n int, d *Doc) *Doc {
+	return &Doc{kind: docNest, n: n, docs: []*Doc{d}}
+}
+
+// Align indents the lines broken inside d to the column d starts at, for
+// layouts that hang after an opening bracket.
+func Align(d *Doc) *Doc {
+	return &Doc{kind: docAlign, docs: []*Doc{d}}
+}
+
+// Group prints d flat if it fits, along with the text that follows it up to
+// the next line break, and broken otherwise. Groups nested in a broken group
+// decide for themselves.
+func Group(d *Doc) *Doc {
+	return &Doc{kind: docGroup, docs: []*Doc{d}}
+}
+
+// IfBreak prints broken inside a broken group and flat inside a flat one.
+// A nil flat prints nothing.
+func IfBreak(broken, flat *Doc) *Doc {
+	if flat == nil {
+		flat = Concat()
+	}
+	return &Doc{kind: docIfBreak, docs: []*Doc{broken}, flat: flat}
+}
+
+// Join places sep between docs.
+func Join(sep *Doc, docs []*Doc) *Doc {
+	parts := make([]*Doc, 0, 2*len(docs))
+	for i, d := range docs {
+		if i > 0 {
+			parts = append(parts, sep)
+		}
+		parts = append(parts, d)
+	}
+	return Concat(parts...)
+}
+
+// Fill places sep between items like Join, but in a broken group prints
+// sep flat unless the item after it does not fit on the current line,
+// packing as many items per line as fit.
+func Fill(sep *Doc, items []*Doc) *Doc {
+	return &Doc{kind: docFill, docs: items, sep: sep}
+}
+
+// wrapped is flat when it fits, and otherwise the document returned by
+// broken for the column it starts at and the width of the text that follows
+// it on the same line.
+func wrapped(flat string, broken func(column, trailing int) *Doc) *Doc {
+	return &Doc{kind: docWrap, text: flat, width: displayWidth(flat), wrap: broken}
+}
+
+// flatString renders d on a single line.
+func flatString(d *Doc) string {
+	var sb strings.Builder
+	writeFlat(&sb, d)
+	return sb.String()
+}
+
+func writeFlat(sb *strings.Builder, d *Doc) {
+	switch d.kind {
+	case docText, docLine, docWrap:
+		sb.WriteString(d.text)
+	case docIfBreak:
+		writeFlat(sb, d.flat)
+	case docFill:
+		for i, item := range d.docs {
+			if i > 0 {
+				writeFlat(sb, d.sep)
+			}
+			writeFlat(sb, item)
+		}
+	default:
+		for _, child := range d.docs {
+			writeFlat(sb, child)
+		}
+	}
+}
+
+// layoutCmd is a document waiting to be printed with an indentation and mode.
+type layoutCmd struct {
+	indent int
+	flat   bool
+	d      *Doc
+	next   int // first remaining item of a fill
+}
+
+// layout prints d starting at column, breaking lines to fit width, followed
+// by trailing columns of text printed by the caller.
+func layout(sb *strings.Builder, d *Doc, width, column, trailing int) {
+	cmds := []layoutCmd{}
+	if trailing > 0 {
+		cmds = append(cmds, layoutCmd{d: Text(strings.Repeat(" ", trailing))})
+	}
+	// The trailing text is measured but not printed
+	bottom := len(cmds)
+	cmds = append(cmds, layoutCmd{indent: column, d: d})
+
+	for len(cmds) > bottom {
+		cmd := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+		d := cmd.d
+
+		switch d.kind {
+		case docText:
+			sb.WriteString(d.text)
+			column += d.width
+		case docLine, docRawLine:
+			if cmd.flat {
+				sb.WriteString(d.text)
+				column += d.width
+			} else if d.kind == docRawLine {
+				sb.WriteString("\n")
+				column = 0
+			} else {
+				sb.WriteString("\n" + strings.Repeat(" ", cmd.indent))
+				column = cmd.indent
+			}
+		case docConcat:
+			for i := len(d.docs) - 1; i >= 0; i-- {
+				cmds = append(cmds, layoutCmd{indent: cmd.indent, flat: cmd.flat, d: d.docs[i]})
+			}
+		case docNest:
+			cmds = append(cmds, layoutCmd{indent: cmd.indent + d.n, flat: cmd.flat, d: d.docs[0]})
+		case docAlign:
+			cmds = append(cmds, layoutCmd{indent: column, flat: cmd.flat, d: d.docs[0]})
+		case docGroup:
+			flat := layoutCmd{indent: cmd.indent, flat: true, d: d.docs[0]}
+			if !cmd.flat && !fits(flat, cmds, width-column) {
+				flat.flat = false
+			}
+			cmds = append(cmds, flat)
+		case docIfBreak:
+			if cmd.flat {
+				cmds = append(cmds, layoutCmd{indent: cmd.indent, flat: true, d: d.flat})
+			} else {
+				cmds = append(cmds, layoutCmd{indent: cmd.indent, d: d.docs[0]})
+			}
+		case docWrap:
+			if cmd.flat || fits(layoutCmd{flat: true, d: d}, cmds, width-column) {
+				sb.WriteString(d.text)
+				column += d.width
+			} else {
+				broken := d.wrap(column, restWidth(cmds, width))
+				cmds = append(cmds, layoutCmd{indent: cmd.indent, d: broken})
+			}
+		case docFill:
+			cmds = layoutFill(cmds, cmd, width-column)
+		}
+	}
+}
+
+// layoutFill schedules the next item of a fill and the separator after it,
+// flat if the item following the separator still fits on the line.
+func layoutFill(cmds []layoutCmd, cmd layoutCmd, width int) []layoutCmd {
+	d := cmd.d
+	if cmd.next >= len(d.docs) {
+		return cmds
+	}
+	// Items are followed by the separator, or by the rest after the last one
+	rest := []layoutCmd{{d: d.sep}}
+	if cmd.next >= len(d.docs)-2 {
+		rest = cmds
+	}
+
+	item := layoutCmd{indent: cmd.indent, flat: true, d: d.docs[cmd.next]}
+	if cmd.next == len(d.docs)-1 {
+		item.flat = cmd.flat || fits(item, rest, width)
+		return append(cmds, item)
+	}
+	item.flat = cmd.flat || fits(item, []layoutCmd{{d: d.sep}}, width)
+
+	// Keep the separator flat if the next item fits after it
+	sep := layoutCmd{indent: cmd.indent, flat: true, d: d.sep}
+	pair := layoutCmd{flat: true, d: Concat(d.docs[cmd.next], d.sep, d.docs[cmd.next+1])}
+	if !cmd.flat && (!item.flat || !fits(pair, rest, width)) {
+		sep.flat = false
+	}
+
+	remaining := cmd
+	remaining.next++
+	return append(cmds, remaining, sep, item)
+}
+
+// fits reports whether next, followed by the rest of the commands up to the
+// next line break, takes no more than width columns.
+func fits(next layoutCmd, rest []layoutCmd, width int) bool {
+	return measure([]layoutCmd{next}, rest, width) <= width
+}
+
+// restWidth returns the width of the text that follows on the current line.
+func restWidth(rest []layoutCmd, width int) int {
+	return measure(nil, rest, width)
+}
+
+// measure returns the width of cmds, followed by the rest of the commands up
+// to the next line break. It stops counting once the width exceeds limit.
+func measure(cmds, rest []layoutCmd, limit int) int {
+	width := 0
+	for width <= limit {
+		if len(cmds) == 0 {
+			if len(rest) == 0 {
+				return width
+			}
+			cmds = append(cmds, rest[len(rest)-1])
+			rest = rest[:len(rest)-1]
+		}
+		cmd := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+		d := cmd.d
+
+		switch d.kind {
+		case docText, docWrap:
+			width += d.width
+		case docLine, docRawLine:
+			if !cmd.flat {
+				return width
+			}
+			width += d.width
+		case docIfBreak:
+			if cmd.flat {
+				cmds = append(cmds, layoutCmd{flat: true, d: d.flat})
+			} else {
+				cmds = append(cmds, layoutCmd{d: d.docs[0]})
+			}
+		case docFill:
+			for i := len(d.docs) - 1; i >= cmd.next; i-- {
+				cmds = append(cmds, layoutCmd{flat: cmd.flat, d: d.docs[i]})
+				if i > cmd.next {
+					cmds = append(cmds, layoutCmd{flat: cmd.flat, d: d.sep})
+				}
+			}
+		default:
+			for i := len(d.docs) - 1; i >= 0; i-- {
+				cmds = append(cmds, layoutCmd{flat: cmd.flat, d: d.docs[i]})
+			}
+		}
+	}
+	return width
+}
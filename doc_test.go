@@ -0,0 +1,81 @@
+package pprint
+
+import (
+	"testing"
+)
+
+func TestDocGroup(t *testing.T) {
+	call := Group(Concat(
+		Text("call("),
+		Nest(4, Concat(SoftLine(), Join(Concat(Text(","), Line()), []*Doc{Text("first"), Text("second")}))),
+		IfBreak(Text(","), nil),
+		SoftLine(),
+		Text(")"),
+	))
+
+	exp := `call(first, second)`
+	if out := New(Width(40)).FormatDoc(call); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	exp = `call(
+    first,
+    second,
+)`
+	if out := New(Width(10)).FormatDoc(call); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestDocAlign(t *testing.T) {
+	items := []*Doc{Text("alpha"), Text("beta"), Text("gamma")}
+	list := Group(Concat(Text("list: "), Align(Concat(Text("<"), Join(Concat(Text(","), Line()), items), Text(">")))))
+
+	exp := `list: <alpha,
+      beta,
+      gamma>`
+	if out := New(Width(20)).FormatDoc(list); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Nested groups break independently, the trailing text counts
+	outer := Group(Concat(Text("["), Align(Join(Concat(Text(","), Line()), []*Doc{list, Text("x")})), Text("]")))
+	exp = `[list: <alpha, beta, gamma>,
+ x]`
+	if out := New(Width(30)).FormatDoc(outer); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestDocFill(t *testing.T) {
+	words := []*Doc{}
+	for _, w := range []string{"one", "two", "three", "four", "five", "six"} {
+		words = append(words, Text(w))
+	}
+	d := Group(Fill(Line(), words))
+
+	exp := `one two three
+four five six`
+	if out := New(Width(14)).FormatDoc(d); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestDocComposeValues(t *testing.T) {
+	pp := New(Width(30), SortMaps())
+	d := Group(Concat(
+		Text("Config("),
+		Align(Concat(pp.Doc(map[string]int{"retries": 3, "timeout": 30}), Text(","), Line(), pp.Doc([]string{"a", "b"}))),
+		Text(")"),
+	))
+
+	exp := `Config({"retries": 3,
+        "timeout": 30},
+       ["a", "b"])`
+	if out := pp.FormatDoc(d); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	if out := New(Width(80)).FormatDoc(d); out != `Config({"retries": 3, "timeout": 30}, ["a", "b"])` {
+		t.Errorf("expected a single line, got %s", out)
+	}
+}
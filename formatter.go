@@ -0,0 +1,79 @@
+package pprint
+
+// Formatter is implemented by types that lay out their own values. The
+// printer calls PrettyFormat, before dispatching on the kind of the value,
+// with a State to write the layout to.
+type Formatter interface {
+	PrettyFormat(s *State)
+}
+
+// State is the state of the printer at a value whose type implements
+// Formatter, or that is printed by a registered Printer. The documents written
+// to it are printed in order in place of the value; child values are built
+// through the printer with Value and Field so that recursion is detected and
+// the depth limit applies to them.
+type State struct {
+	pp      *PrettyPrinter
+	context Context
+	level   int
+	docs    []*Doc
+}
+
+// Indent returns the number of columns added per nesting level.
+func (s *State) Indent() int {
+	return s.pp.indentPerLevel
+}
+
+// Width returns the maximum number of columns of a line.
+func (s *State) Width() int {
+	return s.pp.width
+}
+
+// Depth returns the number of nesting levels printed, 0 when unlimited.
+func (s *State) Depth() int {
+	return s.pp.depth
+}
+
+// Level returns the nesting level of the value, 0 at the top.
+func (s *State) Level() int {
+	return s.level
+}
+
+// Write appends docs to the layout of the value.
+func (s *State) Write(docs ...*Doc) {
+	s.docs = append(s.docs, docs...)
+}
+
+// WriteString appends text, which should not contain newlines, to the layout
+// of the value.
+func (s *State) WriteString(text string) {
+	s.Write(Text(text))
+}
+
+// Value builds the document of a child value one level deeper.
+func (s *State) Value(object any) *Doc {
+	return s.pp.build(object, s.context, s.level+1)
+}
+
+// Field builds the document of a child value one level deeper, reporting
+// cycles found in it under .name.
+func (s *State) Field(name string, object any) *Doc {
+	s.pp.pushPath("." + name)
+	defer s.pp.popPath()
+	return s.Value(object)
+}
+
+// List lays out items between open and close the way slices are, on one
+// line when they fit and one per line otherwise.
+func (s *State) List(open, close string, items []*Doc) *Doc {
+	return s.pp.bracketed(Text(open), Text(close), items, s.pp.compact)
+}
+
+// pprintCustom lays out object with a registered printer or its own
+// PrettyFormat method. Its output is not expected to read back.
+func (pp *PrettyPrinter) pprintCustom(printer Printer, object any, context Context, level int) *Doc {
+	pp.readable = false
+	s := &State{pp: pp, context: context, level: level}
+	printer(s, object)
+	return Concat(s.docs...)
+}
@@ -0,0 +1,368 @@
+package pprint
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// goTabWidth is the number of columns a tab of indentation is counted as
+// when deciding whether a composite literal fits on one line.
+const goTabWidth = 8
+
+// goNode is a Go expression, built once from a value and then laid out.
+// Composite literals are printed either on one line or with one element per
+// line.
+type goNode struct {
+	text      string // leaf text, or the type of a composite literal
+	composite bool
+	items     []goItem
+	suffix    string // text following the closing brace
+	width     int    // width of the expression on a single line
+}
+
+type goItem struct {
+	key   string
+	value *goNode
+}
+
+func goLeaf(text string) *goNode {
+	return &goNode{text: text, width: len(text)}
+}
+
+func goComposite(typ string, items []goItem, suffix string) *goNode {
+	node := &goNode{text: typ, composite: true, items: items, suffix: suffix}
+	node.width = len(typ) + 2 + len(suffix)
+	for i, item := range items {
+		if i > 0 {
+			node.width += 2
+		}
+		if item.key != "" {
+			node.width += len(item.key) + 2
+		}
+		node.width += item.value.width
+	}
+	return node
+}
+
+// flat renders the node on a single line.
+func (n *goNode) flat(sb *strings.Builder) {
+	sb.WriteString(n.text)
+	if !n.composite {
+		return
+	}
+	sb.WriteString("{")
+	for i, item := range n.items {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if item.key != "" {
+			sb.WriteString(item.key + ": ")
+		}
+		item.value.flat(sb)
+	}
+	sb.WriteString("}" + n.suffix)
+}
+
+// layout renders the node starting at column, breaking composite literals
+// that do not fit within width into one element per line.
+func (n *goNode) layout(sb *strings.Builder, indent, column, allowance, width int) {
+	if !n.composite || len(n.items) == 0 || column+n.width+allowance <= width {
+		n.flat(sb)
+		return
+	}
+	sb.WriteString(n.text + "{\n")
+	tabs := strings.Repeat("\t", indent+1)
+	for _, item := range n.items {
+		sb.WriteString(tabs)
+		column := (indent + 1) * goTabWidth
+		if item.key != "" {
+			sb.WriteString(item.key + ": ")
+			column += len(item.key) + 2
+		}
+		item.value.layout(sb, indent+1, column, 1, width)
+		sb.WriteString(",\n")
+	}
+	sb.WriteString(strings.Repeat("\t", indent) + "}" + n.suffix)
+}
+
+// goSource renders object as a gofmt-formatted Go expression.
+func (pp *PrettyPrinter) goSource(object any) string {
+	node := pp.goNode(reflect.ValueOf(object), nil, false, make(Context), 0)
+
+	var sb strings.Builder
+	node.layout(&sb, 0, 0, 0, pp.width)
+
+	// Align keys and values the way gofmt does
+	if formatted, err := format.Source([]byte(sb.String())); err == nil {
+		return string(formatted)
+	}
+	return sb.String()
+}
+
+// goNode builds the expression for value. static is the type of the place the
+// expression is assigned to, nil at the top level; unless it is the dynamic
+// type of value, types must be spelled out. elide reports whether the type
+// of a composite literal may be left out, as inside another composite literal.
+func (pp *PrettyPrinter) goNode(value reflect.Value, static reflect.Type, elide bool, context Context, level int) *goNode {
+	explicit := static == nil || static.Kind() == reflect.Interface
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return goLeaf("nil")
+	}
+	if pp.redaction.value(value) {
+		return pp.goRedacted(value, static, elide)
+	}
+
+	typ := value.Type()
+	switch typ.Kind() {
+	case reflect.Bool:
+		return goLeaf(goConvert(typ, strconv.FormatBool(value.Bool()), explicit && typ != getType[bool]()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		text := pp.goDigits(strconv.FormatInt(value.Int(), 10))
+		return goLeaf(goConvert(typ, text, explicit && typ != getType[int]()))
+	case reflect.Uint8:
+		return goLeaf(goConvert(typ, fmt.Sprintf("0x%02x", value.Uint()), explicit))
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return goLeaf(goConvert(typ, pp.goDigits(strconv.FormatUint(value.Uint(), 10)), explicit))
+	case reflect.Float32, reflect.Float64:
+		text, untyped := goFloat(value.Float(), typ.Bits())
+		text = pp.goDigits(text)
+		if untyped && typ == getType[float64]() {
+			return goLeaf(text)
+		}
+		return goLeaf(goConvert(typ, text, explicit || !untyped))
+	case reflect.Complex64, reflect.Complex128:
+		c := value.Complex()
+		re, reUntyped := goFloat(real(c), typ.Bits()/2)
+		im, imUntyped := goFloat(imag(c), typ.Bits()/2)
+		text := fmt.Sprintf("complex(%s, %s)", re, im)
+		if typ == getType[complex128]() {
+			return goLeaf(text)
+		}
+		return goLeaf(goConvert(typ, text, explicit || !(reUntyped && imUntyped)))
+	case reflect.String:
+		return goLeaf(goConvert(typ, strconv.Quote(value.String()), explicit && typ != getType[string]()))
+	case reflect.Pointer:
+		return pp.goPointer(value, explicit, elide, context, level)
+	case reflect.Map, reflect.Slice:
+		if value.IsNil() {
+			return goNil(typ, explicit)
+		}
+		return pp.goCollection(value, goTypeName(typ, elide && !explicit), context, level)
+	case reflect.Array:
+		return pp.goCollection(value, goTypeName(typ, elide && !explicit), context, level)
+	case reflect.Struct:
+		return pp.goStruct(value, goTypeName(typ, elide && !explicit), context, level)
+	}
+
+	// Functions, channels and unsafe pointers have no literal form
+	pp.readable = false
+	return goNil(typ, explicit)
+}
+
+func (pp *PrettyPrinter) goPointer(value reflect.Value, explicit, elide bool, context Context, level int) *goNode {
+	typ := value.Type()
+	if value.IsNil() {
+		return goNil(typ, explicit)
+	}
+
+	objectId := valueId(value)
+	if context.Contains(objectId) {
+		pp.markCycle()
+		return goNil(typ, explicit)
+	}
+	context[objectId] = len(pp.path)
+	defer delete(context, objectId)
+
+	elem := value.Elem()
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		node := pp.goNode(elem, typ.Elem(), elide && !explicit, context, level)
+		if !elide || explicit {
+			node.text = "&" + node.text
+			node.width++
+		}
+		return node
+	}
+
+	// Take the address of the only element of a single-element slice, as
+	// scalars cannot be addressed directly
+	node := pp.goNode(elem, typ.Elem(), false, context, level)
+	return goComposite("&[]"+goTypeName(typ.Elem(), false), []goItem{{value: node}}, "[0]")
+}
+
+func (pp *PrettyPrinter) goCollection(value reflect.Value, typeName string, context Context, level int) *goNode {
+	if pp.depth > 0 && level >= pp.depth && value.Len() > 0 {
+		pp.readable = false
+		return goLeaf(fmt.Sprintf("%s{ /* ...%s */ }", typeName, elidedCount(value)))
+	}
+
+	objectId := valueId(value)
+	if context.Contains(objectId) {
+		pp.markCycle()
+		return goNil(value.Type(), typeName != "")
+	}
+	context[objectId] = len(pp.path)
+	defer delete(context, objectId)
+
+	typ := value.Type()
+	items := []goItem{}
+	if value.Kind() == reflect.Map {
+		for _, e := range mapEntries(value, pp.sortMaps) {
+			key, entry := e.key, e.value
+			keyNode := pp.goNode(key, typ.Key(), true, context, level+1)
+			var sb strings.Builder
+			keyNode.flat(&sb)
+			pp.pushPath("[" + sb.String() + "]")
+			item := goItem{key: sb.String()}
+			if pp.redaction.key(key) {
+				item.value = pp.goRedacted(entry, typ.Elem(), true)
+			} else {
+				item.value = pp.goNode(entry, typ.Elem(), true, context, level+1)
+			}
+			items = append(items, item)
+			pp.popPath()
+		}
+	} else {
+		for i := 0; i < value.Len(); i++ {
+			pp.pushPath(fmt.Sprintf("[%d]", i))
+			items = append(items, goItem{value: pp.goNode(value.Index(i), typ.Elem(), true, context, level+1)})
+			pp.popPath()
+		}
+	}
+	return goComposite(typeName, items, "")
+}
+
+func (pp *PrettyPrinter) goStruct(value reflect.Value, typeName string, context Context, level int) *goNode {
+	if pp.depth > 0 && level >= pp.depth && !value.IsZero() {
+		pp.readable = false
+		return goLeaf(fmt.Sprintf("%s{ /* ...%s */ }", typeName, elidedCount(value)))
+	}
+
+	typ := value.Type()
+	tags := structTags(typ)
+	items := []goItem{}
+	for i := 0; i < value.NumField(); i++ {
+		// Zero fields are left out of the literal, with or without omitempty
+		tag := tags[i]
+		field := value.Field(i)
+		if tag.skip || field.IsZero() {
+			continue
+		}
+		// Unexported fields cannot be set outside the package of the type
+		if !typ.Field(i).IsExported() {
+			pp.readable = false
+			if !pp.unexported {
+				continue
+			}
+		}
+		name := typ.Field(i).Name
+		key := name
+		if tag.name != "" {
+			// Renamed fields do not compile
+			pp.readable = false
+			key = tag.name
+		}
+
+		pp.pushPath("." + name)
+		item := goItem{key: key}
+		if tag.hint == "secret" || pp.redaction.field(name) || pp.redaction.field(tag.name) {
+			item.value = pp.goRedacted(field, field.Type(), false)
+		} else if rep, readable, ok := pp.goHinted(tag.hint, field); ok && readable {
+			// Hints that are not Go literals are ignored
+			item.value = goLeaf(rep)
+		} else {
+			item.value = pp.goNode(field, field.Type(), false, context, level+1)
+		}
+		items = append(items, item)
+		pp.popPath()
+	}
+	return goComposite(typeName, items, "")
+}
+
+// goHinted formats field as the hint of its field tag asks, without colors.
+func (pp *PrettyPrinter) goHinted(hint string, field reflect.Value) (rep string, readable, ok bool) {
+	if hint == "" || !field.CanInterface() {
+		return "", false, false
+	}
+	rep, readable, ok = pp.hinted(hint, field.Interface())
+	return stripANSI(rep), readable, ok
+}
+
+// goRedacted replaces a sensitive value with the zero value of its type,
+// followed by the redaction marker in a comment.
+func (pp *PrettyPrinter) goRedacted(value reflect.Value, static reflect.Type, elide bool) *goNode {
+	pp.readable = false
+	plain := *pp
+	plain.redaction = nil
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	zero := goLeaf("nil")
+	if value.IsValid() {
+		zero = plain.goNode(reflect.Zero(value.Type()), static, elide, make(Context), 0)
+	}
+
+	var sb strings.Builder
+	zero.flat(&sb)
+	return goLeaf(sb.String() + " /* " + pp.redaction.marker(value) + " */")
+}
+
+// goDigits groups digits when the separator is valid in Go literals.
+func (pp *PrettyPrinter) goDigits(text string) string {
+	if pp.digitSeparator != "_" {
+		return text
+	}
+	return groupDigits(text, "_")
+}
+
+// goTypeName spells typ the way it is written in Go source, or returns ""
+// when the type of a composite literal can be elided.
+func goTypeName(typ reflect.Type, elide bool) string {
+	if elide {
+		return ""
+	}
+	return strings.ReplaceAll(typ.String(), "interface {}", "any")
+}
+
+// goConvert wraps text in a conversion to typ when the constant would
+// otherwise get a different default type.
+func goConvert(typ reflect.Type, text string, explicit bool) string {
+	if !explicit {
+		return text
+	}
+	return goTypeName(typ, false) + "(" + text + ")"
+}
+
+// goNil returns a nil of typ, converted when the type is not implied.
+func goNil(typ reflect.Type, explicit bool) *goNode {
+	if !explicit {
+		return goLeaf("nil")
+	}
+	return goLeaf("(" + goTypeName(typ, false) + ")(nil)")
+}
+
+// goFloat formats f with the shortest representation that reads back
+// exactly. untyped reports whether the text is a floating-point constant,
+// which defaults to float64.
+func goFloat(f float64, bits int) (string, bool) {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()", false
+	case math.IsInf(f, 1):
+		return "math.Inf(1)", false
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)", false
+	}
+	text := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text, true
+}
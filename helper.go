@@ -2,6 +2,7 @@ package pprint
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -10,63 +11,106 @@ import (
 func repr(object any) string {
 	value := reflect.ValueOf(object)
 	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return fmt.Sprintf("(%T)(nil)", object)
+		}
 		intf := reflect.Indirect(value).Interface()
 		return fmt.Sprintf("(%T=%p)&%#v", object, object, intf)
 	}
 	return fmt.Sprintf("%#v", object)
 }
 
+// id returns the address identifying a reference value (pointer, map, slice,
+// func or chan) or 0 for values that are copied and so cannot recurse.
 func id(object any) uintptr {
 	// Get the reflect.Value of the object
-	value := reflect.ValueOf(object)
+	return valueId(reflect.ValueOf(object))
+}
 
-	// Check if the object is addressable (has a memory address)
-	if value.Kind() == reflect.Ptr || value.CanAddr() {
+// valueId is id for a reflect.Value, which may come from an unexported field.
+func valueId(value reflect.Value) uintptr {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Func, reflect.Chan, reflect.UnsafePointer:
 		// Use uintptr to represent the memory address
 		return value.Pointer()
+	case reflect.Slice:
+		// Empty slices and slices of zero-size elements may share an address
+		if value.Cap() == 0 || value.Type().Elem().Size() == 0 {
+			return 0
+		}
+		return value.Pointer()
 	}
 
-	// If not addressable, create a pointer to the object to get its address
-	return reflect.ValueOf(&object).Pointer()
+	return 0
+}
+
+// formatPath joins access path segments such as ".Field" and "[2]" into a
+// path relative to the root value, written as ".".
+func formatPath(path []string) string {
+	joined := strings.Join(path, "")
+	if strings.HasPrefix(joined, ".") {
+		return joined
+	}
+	return "." + joined
 }
 
 func getType[T any]() reflect.Type {
 	return reflect.TypeOf((*T)(nil)).Elem()
 }
 
-func copyContext(context Context) Context {
-	// Create a new map to store the copied data
-	copy := make(Context, len(context))
-
-	// Copy each key-value pair
-	for key, value := range context {
-		copy[key] = value
+// formatNumber formats integers of any size and floats with sep between
+// groups of three digits. Bytes and addresses are left in hex.
+func formatNumber(value reflect.Value, sep string) (string, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return groupDigits(strconv.FormatInt(value.Int(), 10), sep), true
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return groupDigits(strconv.FormatUint(value.Uint(), 10), sep), true
+	case reflect.Float32, reflect.Float64:
+		// Spell out the integer part unless it would be too long
+		f, format := value.Float(), byte('g')
+		if math.Abs(f) < 1e21 {
+			format = 'f'
+		}
+		return groupDigits(strconv.FormatFloat(f, format, -1, value.Type().Bits()), sep), true
 	}
-
-	return copy
+	return "", false
 }
 
-func formatWithUnderscores(num int) string {
-	// Convert the integer to a string
-	numStr := strconv.Itoa(num)
+// groupDigits inserts sep between groups of three digits in the integer
+// part of a formatted number, keeping the sign, fraction and exponent.
+func groupDigits(text, sep string) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+	integer, rest := text, ""
+	if i := strings.IndexAny(text, ".eE"); i >= 0 {
+		integer, rest = text[:i], text[i:]
+	}
+	if len(integer) <= 3 || strings.Trim(integer, "0123456789") != "" {
+		// Nothing to group, or NaN and Inf
+		return sign + text
+	}
 
-	// Add underscores for thousands separators
 	var sb strings.Builder
-	length := len(numStr)
-	for i, digit := range numStr {
-		sb.WriteRune(digit)
-		// Add an underscore after every 3 digits (except at the end)
-		if (length-i-1)%3 == 0 && i != length-1 {
-			sb.WriteRune('_')
+	sb.WriteString(sign)
+	for i := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(sep)
 		}
+		sb.WriteByte(integer[i])
 	}
+	sb.WriteString(rest)
 	return sb.String()
 }
 
-func recursion(object any) string {
-	objectType := reflect.TypeOf(object).Name()
-	objectId := id(object)
-	return fmt.Sprintf("<Recursion on %s with id=%s>", objectType, repr(objectId))
+// cyclePrefix starts the marker of a reference back to an enclosing object.
+const cyclePrefix = "<cycle -> "
+
+// cycleMarker marks a reference back to the enclosing object at path.
+func cycleMarker(path string) string {
+	return cyclePrefix + path + ">"
 }
 
 func wrapBytesRepr(object []byte, width, allowance int) []string {
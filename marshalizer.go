@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -19,15 +20,20 @@ import (
 
 type Serializer func(val reflect.Value, mr Marshalizer) any
 
+// MarshalizerContext maps the id of each object being serialized to the
+// length of its access path, like Context.
 type MarshalizerContext map[uintptr]int
 
 func (ctx MarshalizerContext) Contains(objectId uintptr) bool {
+	if objectId == 0 {
+		return false
+	}
 	_, exists := ctx[objectId]
 	return exists
 }
 
-func (ctx MarshalizerContext) Set(objectId uintptr) {
-	ctx[objectId] = 1
+func (ctx MarshalizerContext) Set(objectId uintptr, depth int) {
+	ctx[objectId] = depth
 }
 
 func (ctx MarshalizerContext) Del(objectId uintptr) {
@@ -44,6 +50,8 @@ type Marshalizer struct {
 	includePrivateFields bool
 	includeImplements    bool
 	registry             SerializersRegistry
+	redaction            *Redaction
+	path                 *[]string // access path of the value being serialized
 }
 
 func NewMarshalizer(includePrivateFields bool, escapeHTML bool, emptyRegistry bool, includeImplements bool) MarshalizerInterface {
@@ -100,6 +108,7 @@ func NewMarshalizer(includePrivateFields bool, escapeHTML bool, emptyRegistry bo
 
 	mr := &Marshalizer{
 		context:              make(MarshalizerContext),
+		path:                 new([]string),
 		escapeHTML:           escapeHTML,
 		includePrivateFields: includePrivateFields,
 		includeImplements:    includeImplements,
@@ -109,6 +118,14 @@ func NewMarshalizer(includePrivateFields bool, escapeHTML bool, emptyRegistry bo
 	return mr
 }
 
+// NewRedactingMarshalizer returns a Marshalizer like NewMarshalizer that
+// replaces sensitive values with <redacted> as policy describes.
+func NewRedactingMarshalizer(policy Redaction, includePrivateFields bool, escapeHTML bool, emptyRegistry bool, includeImplements bool) MarshalizerInterface {
+	mr := NewMarshalizer(includePrivateFields, escapeHTML, emptyRegistry, includeImplements).(*Marshalizer)
+	mr.SetRedaction(policy)
+	return mr
+}
+
 func (mr Marshalizer) String() string {
 	result, err := mr.Serialize(mr)
 	if err != nil {
@@ -141,6 +158,42 @@ func (mr Marshalizer) Serialize(object any) ([]byte, error) {
 	return result, nil
 }
 
+// SetRedaction replaces sensitive values with <redacted> as policy describes,
+// the way the Redact option does for a PrettyPrinter.
+func (mr *Marshalizer) SetRedaction(policy Redaction) {
+	mr.redaction = &policy
+}
+
+// pushPath and popPath maintain the access path of the value being
+// serialized.
+func (mr Marshalizer) pushPath(segment string) {
+	if mr.path != nil {
+		*mr.path = append(*mr.path, segment)
+	}
+}
+
+func (mr Marshalizer) popPath() {
+	if mr.path != nil {
+		*mr.path = (*mr.path)[:len(*mr.path)-1]
+	}
+}
+
+func (mr Marshalizer) pathLen() int {
+	if mr.path == nil {
+		return 0
+	}
+	return len(*mr.path)
+}
+
+// pathPrefix returns the access path of the enclosing value whose path has n
+// segments.
+func (mr Marshalizer) pathPrefix(n int) string {
+	if mr.path == nil {
+		return formatPath(nil)
+	}
+	return formatPath((*mr.path)[:min(n, len(*mr.path))])
+}
+
 func (mr Marshalizer) AddKind(kind reflect.Kind, serializer Serializer) {
 	mr.registry.AddKind(kind, serializer)
 }
@@ -173,13 +226,15 @@ func serialize(object any, mr Marshalizer) any {
 
 	objectId := id(object)
 	if mr.context.Contains(objectId) {
-		// return fmt.Sprintf("(%T=%p)[Recursion Exceeded]", object, object)
-		return fmt.Sprintf("(%T=%p)[Recursion Exceeded]", object, object)
+		return cycleMarker(mr.pathPrefix(mr.context[objectId]))
 	}
 
-	mr.context.Set(objectId)
-
 	val := reflect.ValueOf(object)
+	if mr.redaction.value(val) {
+		return mr.redaction.marker(val)
+	}
+
+	mr.context.Set(objectId, mr.pathLen())
 
 	if serializer, exists := mr.registry.typeSerializers[val.Type()]; exists {
 		r := serializer(val, mr)
@@ -252,6 +307,7 @@ func SerializeStruct(val reflect.Value, mr Marshalizer) any {
 	// Handle structs
 	m := make(map[string]any)
 	typ := val.Type()
+	tags := structTags(typ)
 	// typ := reflect.TypeOf(object)
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
@@ -263,7 +319,13 @@ func SerializeStruct(val reflect.Value, mr Marshalizer) any {
 			}
 			continue // Skip unexported fields
 		}
+		if tags[i].hint == "secret" || mr.redaction.field(fieldType.Name) || mr.redaction.field(tags[i].name) {
+			m[fieldType.Name] = mr.redaction.marker(field)
+			continue
+		}
+		mr.pushPath("." + fieldType.Name)
 		m[fieldType.Name] = serialize(field.Interface(), mr)
+		mr.popPath()
 	}
 	return m
 }
@@ -272,7 +334,9 @@ func SerializeSlice(val reflect.Value, mr Marshalizer) any {
 	// Handle slices
 	result := make([]any, val.Len())
 	for i := 0; i < val.Len(); i++ {
+		mr.pushPath(fmt.Sprintf("[%d]", i))
 		result[i] = serialize(val.Index(i).Interface(), mr)
+		mr.popPath()
 	}
 	return result
 }
@@ -280,13 +344,26 @@ func SerializeSlice(val reflect.Value, mr Marshalizer) any {
 func SerializeMap(val reflect.Value, mr Marshalizer) any {
 	// Handle maps
 	result := make(map[string]any)
-	for _, key := range val.MapKeys() {
-		result[fmt.Sprintf("%v", key.Interface())] = serialize(val.MapIndex(key).Interface(), mr)
+	for _, entry := range mapEntries(val, false) {
+		key := entry.key
+		if mr.redaction.key(key) {
+			result[fmt.Sprintf("%v", key.Interface())] = mr.redaction.marker(entry.value)
+			continue
+		}
+		mr.pushPath("[" + pathKey(key) + "]")
+		result[fmt.Sprintf("%v", key.Interface())] = serialize(entry.value.Interface(), mr)
+		mr.popPath()
 	}
 	return result
 }
 
 func SerializeFuncSignature(val reflect.Value, mr Marshalizer) any {
+	return funcSignature(val)
+}
+
+// funcSignature describes a function by its name and signature, as in
+// main.TempFunc func(interface{}, int) string.
+func funcSignature(val reflect.Value) string {
 	// Automatically generate a function descriptor
 	funcType := val.Type()
 
@@ -392,6 +469,18 @@ func joinFuncSignature(name string, params, results []string) string {
 	return strings.TrimSpace(signature)
 }
 
+// pathKey writes a map key in an access path the way the printer does.
+func pathKey(key reflect.Value) string {
+	key = unwrapInterface(key)
+	switch {
+	case !key.IsValid():
+		return "nil"
+	case key.Kind() == reflect.String:
+		return strconv.Quote(key.String())
+	}
+	return fmt.Sprint(key.Interface())
+}
+
 // Function to clean up spaces between type names
 func removeSpaces(typeStr string) string {
 	return strings.ReplaceAll(typeStr, " ", "")
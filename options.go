@@ -0,0 +1,187 @@
+package pprint
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Option configures a PrettyPrinter built with New or derived with With.
+type Option func(pp *PrettyPrinter)
+
+// Output sets the stream PPrint writes to, os.Stdout by default.
+func Output(stream io.Writer) Option {
+	return func(pp *PrettyPrinter) {
+		pp.stream = stream
+	}
+}
+
+// Indent sets the number of spaces added for each nesting level, 1 by default.
+func Indent(indent int) Option {
+	return func(pp *PrettyPrinter) {
+		pp.indentPerLevel = indent
+	}
+}
+
+// Width sets the desired maximum number of characters per line, 80 by default.
+func Width(width int) Option {
+	return func(pp *PrettyPrinter) {
+		pp.width = width
+	}
+}
+
+// Depth limits the number of nesting levels printed; deeper levels are
+// replaced by placeholders. The default of 0 means no limit.
+func Depth(depth int) Option {
+	return func(pp *PrettyPrinter) {
+		pp.depth = depth
+	}
+}
+
+// Compact packs as many slice items as fit on each line.
+func Compact() Option {
+	return func(pp *PrettyPrinter) {
+		pp.compact = true
+	}
+}
+
+// SortMaps prints map entries sorted by key.
+func SortMaps() Option {
+	return func(pp *PrettyPrinter) {
+		pp.sortMaps = true
+	}
+}
+
+// ThinSpace is a digit separator for reports read by humans.
+const ThinSpace = "\u2009"
+
+// UnderscoreNumbers separates groups of thousands in numbers with
+// underscores, as in Go literals.
+func UnderscoreNumbers() Option {
+	return DigitSeparator("_")
+}
+
+// DigitSeparator separates groups of thousands in integers and in the integer
+// part of floats with sep, such as "_", "," or ThinSpace. Numbers grouped
+// with anything but "_" do not read back, so they make the output
+// unreadable. GoSyntax ignores separators other than "_".
+func DigitSeparator(sep string) Option {
+	return func(pp *PrettyPrinter) {
+		pp.digitSeparator = sep
+	}
+}
+
+// NamedTypes prints values of named scalar types as conversions, such as
+// Status(3), instead of as bare values of their underlying type.
+func NamedTypes() Option {
+	return func(pp *PrettyPrinter) {
+		pp.namedTypes = true
+	}
+}
+
+// Colors highlights keys, strings, numbers, bools and nil, type names,
+// pointer addresses and markers with the ANSI escape sequences of theme,
+// such as DarkTheme or LightTheme. Layout is the same as without colors.
+// GoSyntax output is never colored.
+func Colors(theme Theme) Option {
+	return func(pp *PrettyPrinter) {
+		pp.theme = theme
+	}
+}
+
+// UnexportedFields prints the values of unexported struct fields, marking
+// their names with a leading ~, instead of <InaccessibleField>. The output is
+// then no longer readable.
+func UnexportedFields() Option {
+	return func(pp *PrettyPrinter) {
+		pp.unexported = true
+	}
+}
+
+// HideAddresses leaves pointer, func and channel addresses out of the
+// output, so that it is the same on every run, as in (*T)&{...}.
+func HideAddresses() Option {
+	return func(pp *PrettyPrinter) {
+		pp.hideAddresses = true
+	}
+}
+
+// SharedRefs labels the pointers, maps and slices that are reached more than
+// once, printing &1 = value where one is first printed and *1 in its other
+// places, whether it is shared or refers back to itself. The output is then
+// no longer readable. GoSyntax output is not labeled.
+func SharedRefs() Option {
+	return func(pp *PrettyPrinter) {
+		pp.sharedRefs = true
+	}
+}
+
+// Redact replaces sensitive values with <redacted> as policy describes. Fields
+// tagged `pprint:",secret"` are redacted without a policy.
+func Redact(policy Redaction) Option {
+	return func(pp *PrettyPrinter) {
+		pp.redaction = &policy
+	}
+}
+
+// GoSyntax prints values as gofmt-formatted Go expressions that compile back
+// to the value. Values without a literal form, such as funcs, channels and
+// recursive references, are printed as nil and make the output unreadable.
+// Unexported fields are left out unless UnexportedFields is also given, and
+// make the output unreadable when set.
+func GoSyntax() Option {
+	return func(pp *PrettyPrinter) {
+		pp.goSyntax = true
+	}
+}
+
+// New returns a PrettyPrinter with the default settings modified by opts.
+// It panics if the resulting configuration is invalid.
+func New(opts ...Option) *PrettyPrinter {
+	pp := &PrettyPrinter{
+		stream:         os.Stdout,
+		width:          80,
+		indentPerLevel: 1,
+		dispatchMap:    defaultDispatchMap,
+		registry:       NewRegistry(DefaultRegistry),
+	}
+	pp.apply(opts)
+	return pp
+}
+
+// With returns a copy of the printer with opts applied on top of its settings.
+// The receiver is left unchanged; printers added to the registry of the copy
+// are not seen by the receiver.
+func (pp PrettyPrinter) With(opts ...Option) *PrettyPrinter {
+	derived := pp
+	if derived.registry != nil {
+		derived.registry = NewRegistry(derived.registry)
+	}
+	derived.apply(opts)
+	return &derived
+}
+
+func (pp *PrettyPrinter) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(pp)
+	}
+	if err := pp.validate(); err != nil {
+		panic(err)
+	}
+	if pp.stream == nil {
+		pp.stream = os.Stdout
+	}
+}
+
+func (pp *PrettyPrinter) validate() error {
+	if pp.indentPerLevel < 0 {
+		return fmt.Errorf("indent must be >= 0")
+	}
+	if pp.depth < 0 {
+		return fmt.Errorf("depth must be >= 0")
+	}
+	if pp.width <= 0 {
+		return fmt.Errorf("width must be > 0")
+	}
+	return nil
+}
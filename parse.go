@@ -0,0 +1,1131 @@
+package pprint
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ParseError reports malformed input, or input that does not fit the target
+// value, at a position in the text.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("pprint: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Parse reads text printed by a PrettyPrinter, in the default layout or with
+// GoSyntax, and stores the value it describes in the value pointed to by
+// target.
+//
+// Maps, slices, arrays, struct literals, strings split over several lines,
+// numbers with separated digits and wrapped hex byte blocks are understood.
+// Colors are ignored.
+// Type names and pointer addresses in the text are skipped, the target
+// decides the types. Funcs, channels and recursion markers are left zero.
+func Parse(text string, target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("pprint: Parse target must be a non-nil pointer, got %T", target)
+	}
+
+	tokens, err := lex(stripANSI(text))
+	if err != nil {
+		return err
+	}
+	p := &textParser{tokens: tokens}
+	n, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return tok.errorf("unexpected %s after value", tok)
+	}
+	return decode(n, value.Elem())
+}
+
+// -------------------------------------------------------------------------------------------------------
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokMarker // <nil>, <InaccessibleField>, <cycle -> .path>
+	tokPunct
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (tok token) String() string {
+	if tok.kind == tokEOF {
+		return "end of input"
+	}
+	return strconv.Quote(tok.text)
+}
+
+func (tok token) errorf(format string, args ...any) error {
+	return &ParseError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func lex(text string) ([]token, error) {
+	var tokens []token
+	line, column := 1, 1
+	i := 0
+
+	advance := func(n int) {
+		for _, r := range text[i : i+n] {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		i += n
+	}
+
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		start := token{line: line, column: column}
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(size)
+			continue
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return nil, start.errorf("unterminated comment")
+			}
+			advance(end + 4)
+			continue
+		case strings.HasPrefix(text[i:], "//"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			advance(end)
+			continue
+		}
+
+		n := 0
+		switch {
+		case r == '"':
+			n = scanQuoted(text[i:])
+			if n < 0 {
+				return nil, start.errorf("unterminated string")
+			}
+			start.kind = tokString
+		case r == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				return nil, start.errorf("unterminated raw string")
+			}
+			n = end + 2
+			start.kind = tokString
+		case r == '<':
+			n = scanMarker(text[i:])
+			if n < 0 {
+				return nil, start.errorf("unterminated marker")
+			}
+			start.kind = tokMarker
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(text) && isDigit(text[i+1])):
+			n = scanNumber(text[i:])
+			start.kind = tokNumber
+		case r == '_' || unicode.IsLetter(r):
+			n = strings.IndexFunc(text[i:], func(r rune) bool {
+				return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if n < 0 {
+				n = len(text) - i
+			}
+			start.kind = tokIdent
+		case strings.HasPrefix(text[i:], "..."):
+			n = 3
+			start.kind = tokPunct
+		default:
+			n = size
+			start.kind = tokPunct
+		}
+
+		start.text = text[i : i+n]
+		tokens = append(tokens, start)
+		advance(n)
+	}
+
+	return append(tokens, token{kind: tokEOF, line: line, column: column}), nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// scanQuoted returns the length of the double-quoted string at the start of
+// text, or -1 if it is not terminated on the same line.
+func scanQuoted(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// scanMarker returns the length of the marker, such as <nil> or
+// <cycle -> .path>, at the start of text, or -1 if it is not closed. Neither
+// the arrow of a cycle marker nor a quoted map key in its path ends it.
+func scanMarker(text string) int {
+	i := 1
+	if strings.HasPrefix(text, cyclePrefix) {
+		i = len(cyclePrefix)
+	}
+	for ; i < len(text); i++ {
+		switch text[i] {
+		case '>':
+			return i + 1
+		case '"':
+			n := scanQuoted(text[i:])
+			if n < 0 {
+				return -1
+			}
+			i += n - 1
+		}
+	}
+	return -1
+}
+
+// scanNumber returns the length of the number, or hex byte block word, at
+// the start of text.
+func scanNumber(text string) int {
+	hexadecimal := strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X")
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '_' || c == '.' || isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+		case (c == '+' || c == '-') && i > 0:
+			// Exponent sign: 1e+10, 0x1p-2
+			prev := text[i-1]
+			if hexadecimal && prev != 'p' && prev != 'P' || !hexadecimal && prev != 'e' && prev != 'E' {
+				return i
+			}
+		default:
+			return i
+		}
+	}
+	return len(text)
+}
+
+// -------------------------------------------------------------------------------------------------------
+// Parser
+
+type nodeKind int
+
+const (
+	nodeNil nodeKind = iota
+	nodeBool
+	nodeNumber
+	nodeComplex
+	nodeString
+	nodeBytes
+	nodeList      // [a, b], (a,) and composite literals without keys
+	nodeComposite // {k: v}, T{F: v}
+	nodeMarker
+	nodeElided
+)
+
+type node struct {
+	kind    nodeKind
+	tok     token // first token, for error positions
+	text    string
+	imag    string
+	items   []*node
+	entries []entry
+}
+
+type entry struct {
+	field string // struct field name, or "" when key is set
+	key   *node
+	value *node
+}
+
+type textParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *textParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *textParser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *textParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *textParser) is(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *textParser) expect(text string) error {
+	if tok := p.next(); tok.kind != tokPunct || tok.text != text {
+		return tok.errorf("expected %q, found %s", text, tok)
+	}
+	return nil
+}
+
+func (p *textParser) parseValue() (*node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokEOF:
+		return nil, tok.errorf("unexpected end of input")
+	case tokString:
+		return p.parseString()
+	case tokNumber:
+		return p.parseWords()
+	case tokMarker:
+		p.next()
+		if tok.text == "<nil>" {
+			return &node{kind: nodeNil, tok: tok}, nil
+		}
+		return &node{kind: nodeMarker, tok: tok, text: tok.text}, nil
+	case tokIdent:
+		return p.parseIdent()
+	}
+
+	switch tok.text {
+	case "&":
+		p.next()
+		return p.parseValue()
+	case "-", "+":
+		p.next()
+		n, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if n.kind != nodeNumber {
+			return nil, tok.errorf("expected a number after %q", tok.text)
+		}
+		if tok.text == "-" {
+			n.text = negate(n.text)
+		}
+		return n, nil
+	case "(":
+		return p.parseParen()
+	case "[":
+		if p.startsType() {
+			return p.parseTyped()
+		}
+		return p.parseList()
+	case "{":
+		return p.parseComposite()
+	case "*":
+		return p.parseTyped()
+	}
+	return nil, tok.errorf("unexpected %s", tok)
+}
+
+func negate(text string) string {
+	if strings.HasPrefix(text, "-") {
+		return text[1:]
+	}
+	return "-" + text
+}
+
+// parseString reads a string literal, joining literals split over several
+// lines with "+".
+func (p *textParser) parseString() (*node, error) {
+	first := p.peek()
+	var sb strings.Builder
+	for {
+		tok := p.next()
+		s, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, tok.errorf("invalid string literal %s", tok.text)
+		}
+		sb.WriteString(s)
+
+		if p.is("+") && p.peekAt(1).kind == tokString {
+			p.next()
+		}
+		if p.peek().kind != tokString {
+			return &node{kind: nodeString, tok: first, text: sb.String()}, nil
+		}
+	}
+}
+
+// parseWords reads a number, or consecutive words of a wrapped hex byte block.
+func (p *textParser) parseWords() (*node, error) {
+	first := p.next()
+	words := []string{first.text}
+	for tok := p.peek(); tok.kind == tokNumber || tok.kind == tokIdent && isHex(tok.text); tok = p.peek() {
+		words = append(words, p.next().text)
+	}
+	if len(words) == 1 && !isHex(first.text) || len(words) == 1 && isNumber(first.text) {
+		return &node{kind: nodeNumber, tok: first, text: first.text}, nil
+	}
+	return &node{kind: nodeBytes, tok: first, text: strings.Join(words, "")}, nil
+}
+
+func isHex(text string) bool {
+	if len(text)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; !isDigit(c) && !('a' <= c && c <= 'f') && !('A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(text string) bool {
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseUint(text, 0, 64)
+	return err == nil
+}
+
+func (p *textParser) parseIdent() (*node, error) {
+	tok := p.peek()
+	switch tok.text {
+	case "true", "false":
+		p.next()
+		return &node{kind: nodeBool, tok: tok, text: tok.text}, nil
+	case "nil":
+		p.next()
+		return &node{kind: nodeNil, tok: tok}, nil
+	case "complex":
+		if p.peekAt(1).text == "(" {
+			return p.parseComplex()
+		}
+	case "math":
+		if p.peekAt(1).text == "." {
+			return p.parseMath()
+		}
+	}
+
+	switch next := p.peekAt(1); {
+	case next.kind == tokPunct && (next.text == "{" || next.text == "(" || next.text == "." || next.text == "["):
+		return p.parseTyped()
+	case tok.text == "map" || tok.text == "struct" || tok.text == "func" || tok.text == "chan" || tok.text == "interface":
+		return p.parseTyped()
+	case isHex(tok.text):
+		p.next()
+		words := []string{tok.text}
+		for tok := p.peek(); tok.kind == tokNumber || tok.kind == tokIdent && isHex(tok.text); tok = p.peek() {
+			words = append(words, p.next().text)
+		}
+		return &node{kind: nodeBytes, tok: tok, text: strings.Join(words, "")}, nil
+	}
+	return nil, tok.errorf("unexpected identifier %s", tok)
+}
+
+// parseComplex reads complex(re, im) as printed with GoSyntax.
+func (p *textParser) parseComplex() (*node, error) {
+	tok := p.next()
+	p.next()
+	re, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	im, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if re.kind != nodeNumber || im.kind != nodeNumber {
+		return nil, tok.errorf("expected numbers in complex()")
+	}
+	return &node{kind: nodeComplex, tok: tok, text: re.text, imag: im.text}, nil
+}
+
+// parseMath reads math.NaN() and math.Inf(sign) as printed with GoSyntax.
+func (p *textParser) parseMath() (*node, error) {
+	tok := p.next()
+	p.next()
+	name := p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	switch name.text {
+	case "NaN":
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeNumber, tok: tok, text: "NaN"}, nil
+	case "Inf":
+		sign, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(sign.text, "-") {
+			return &node{kind: nodeNumber, tok: tok, text: "-Inf"}, nil
+		}
+		return &node{kind: nodeNumber, tok: tok, text: "+Inf"}, nil
+	}
+	return nil, name.errorf("unexpected math.%s", name.text)
+}
+
+// startsType reports whether the "[" at the current position starts a slice
+// or array type rather than a list.
+func (p *textParser) startsType() bool {
+	after := p.peekAt(2)
+	if p.peekAt(1).kind == tokNumber && after.text == "]" {
+		after = p.peekAt(3)
+	} else if p.peekAt(1).text != "]" {
+		return false
+	}
+	return after.kind == tokIdent || after.kind == tokPunct && (after.text == "*" || after.text == "[")
+}
+
+// parseTyped reads a composite literal or conversion preceded by a type.
+func (p *textParser) parseTyped() (*node, error) {
+	tok := p.peek()
+	if err := p.skipType(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.is("{"):
+		n, err := p.parseComposite()
+		if err != nil {
+			return nil, err
+		}
+		n.tok = tok
+		return p.parseIndex(n)
+	case p.is("("):
+		// Conversion: T(x)
+		p.next()
+		n, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	}
+	return nil, p.peek().errorf("expected \"{\" or \"(\" after type, found %s", p.peek())
+}
+
+// parseIndex reads the [0] that follows the single-element slice used by
+// GoSyntax to take the address of a scalar.
+func (p *textParser) parseIndex(n *node) (*node, error) {
+	if !p.is("[") {
+		return n, nil
+	}
+	tok := p.next()
+	index := p.next()
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	i, err := strconv.Atoi(index.text)
+	if err != nil || n.kind != nodeList || i < 0 || i >= len(n.items) {
+		return nil, tok.errorf("invalid index %s", index)
+	}
+	return n.items[i], nil
+}
+
+// skipType consumes a type expression.
+func (p *textParser) skipType() error {
+	tok := p.next()
+	switch {
+	case tok.text == "*":
+		return p.skipType()
+	case tok.text == "[":
+		if !p.is("]") {
+			p.next()
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+		return p.skipType()
+	case tok.text == "map":
+		if err := p.expect("["); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+		return p.skipType()
+	case tok.text == "chan":
+		if p.is("<") {
+			p.next()
+			p.next()
+		}
+		return p.skipType()
+	case tok.text == "struct" || tok.text == "interface":
+		return p.skipBalanced("{", "}")
+	case tok.text == "func":
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return err
+		}
+		switch next := p.peek(); {
+		case next.text == "(":
+			return p.skipBalanced("(", ")")
+		case next.kind == tokIdent || next.text == "*" || next.text == "[":
+			return p.skipType()
+		}
+		return nil
+	case tok.kind == tokIdent:
+		if p.is(".") {
+			p.next()
+			if name := p.next(); name.kind != tokIdent {
+				return name.errorf("expected a type name, found %s", name)
+			}
+		}
+		if p.is("[") && !p.startsType() && p.peekAt(1).kind == tokIdent {
+			// Type arguments of a generic type
+			return p.skipBalanced("[", "]")
+		}
+		return nil
+	}
+	return tok.errorf("expected a type, found %s", tok)
+}
+
+func (p *textParser) skipBalanced(open, close string) error {
+	if err := p.expect(open); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		tok := p.next()
+		switch {
+		case tok.kind == tokEOF:
+			return tok.errorf("expected %q", close)
+		case tok.kind != tokPunct:
+		case tok.text == open:
+			depth++
+		case tok.text == close:
+			depth--
+		}
+	}
+	return nil
+}
+
+// parseParen reads a pointer prefix such as (*T=0xc000010000)& or (*T)&, a
+// conversion such as (*T)(nil), a single item list (x,), a complex number
+// (1+2i), or a parenthesized string or hex byte block.
+func (p *textParser) parseParen() (*node, error) {
+	open := p.next()
+
+	// Try a type first and fall back to a parenthesized value
+	start := p.pos
+	if err := p.skipType(); err == nil {
+		switch {
+		case p.is("="):
+			p.next()
+			p.next() // address
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			if !p.is("&") {
+				return nil, p.peek().errorf("expected \"&\" after pointer prefix, found %s", p.peek())
+			}
+			p.next()
+			return p.parseValue()
+		case p.is(")") && p.peekAt(1).text == "&":
+			// Pointer prefix without an address
+			p.next()
+			p.next()
+			return p.parseValue()
+		case p.is(")") && p.peekAt(1).text == "(":
+			p.next()
+			p.next()
+			n, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	p.pos = start
+
+	n, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.is(","):
+		p.next()
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeList, tok: open, items: []*node{n}}, nil
+	case (p.is("+") || p.is("-")) && n.kind == nodeNumber:
+		sign := p.next()
+		im := p.next()
+		if im.kind != tokNumber || !strings.HasSuffix(im.text, "i") {
+			return nil, im.errorf("expected an imaginary number, found %s", im)
+		}
+		text := strings.TrimSuffix(im.text, "i")
+		if sign.text == "-" {
+			text = "-" + text
+		}
+		n = &node{kind: nodeComplex, tok: open, text: n.text, imag: text}
+	}
+	return n, p.expect(")")
+}
+
+func (p *textParser) parseList() (*node, error) {
+	open := p.next()
+	n := &node{kind: nodeList, tok: open}
+	for !p.is("]") {
+		if p.is("...") {
+			return p.parseElided(open, "]")
+		}
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	return n, p.expect("]")
+}
+
+// parseComposite reads {k: v, ...}, {F: v, ...} or {a, b, ...}.
+func (p *textParser) parseComposite() (*node, error) {
+	open := p.next()
+	n := &node{kind: nodeComposite, tok: open}
+	for !p.is("}") {
+		if p.is("...") {
+			return p.parseElided(open, "}")
+		}
+
+		var e entry
+		if tok := p.peek(); tok.kind == tokIdent && p.peekAt(1).text == ":" {
+			e.field = tok.text
+			p.next()
+		} else {
+			key, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if !p.is(":") {
+				n.items = append(n.items, key)
+				if !p.is(",") {
+					break
+				}
+				p.next()
+				continue
+			}
+			e.key = key
+		}
+		p.next() // ":"
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		e.value = value
+		n.entries = append(n.entries, e)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+
+	if len(n.items) > 0 {
+		if len(n.entries) > 0 {
+			return nil, open.errorf("mixed keyed and positional elements")
+		}
+		n.kind = nodeList
+	}
+	return n, p.expect("}")
+}
+
+// parseElided reads the rest of a placeholder left by the depth limit.
+func (p *textParser) parseElided(open token, close string) (*node, error) {
+	for !p.is(close) {
+		if tok := p.next(); tok.kind == tokEOF {
+			return nil, tok.errorf("expected %q", close)
+		}
+	}
+	p.next()
+	return &node{kind: nodeElided, tok: open}, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// Decoder
+
+func decode(n *node, v reflect.Value) error {
+	switch n.kind {
+	case nodeNil, nodeMarker:
+		v.SetZero()
+		return nil
+	case nodeElided:
+		return n.tok.errorf("value was elided by the depth limit")
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return n.tok.errorf("cannot decode into non-empty interface %s", v.Type())
+		}
+		g, err := generic(n)
+		if err != nil {
+			return err
+		}
+		if g != nil {
+			v.Set(reflect.ValueOf(g))
+		}
+		return nil
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if err := decode(n, ptr.Elem()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	case reflect.Bool:
+		if n.kind != nodeBool {
+			return mismatch(n, v)
+		}
+		v.SetBool(n.text == "true")
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n.kind != nodeNumber {
+			return mismatch(n, v)
+		}
+		i, err := strconv.ParseInt(n.text, 0, v.Type().Bits())
+		if err != nil {
+			return n.tok.errorf("invalid %s %s", v.Type(), n.text)
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n.kind != nodeNumber {
+			return mismatch(n, v)
+		}
+		u, err := strconv.ParseUint(n.text, 0, v.Type().Bits())
+		if err != nil {
+			return n.tok.errorf("invalid %s %s", v.Type(), n.text)
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if n.kind != nodeNumber {
+			return mismatch(n, v)
+		}
+		f, err := parseFloat(n.text, v.Type().Bits())
+		if err != nil {
+			return n.tok.errorf("invalid %s %s", v.Type(), n.text)
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Complex64, reflect.Complex128:
+		c, err := parseComplex(n, v.Type().Bits()/2)
+		if err != nil {
+			return err
+		}
+		v.SetComplex(c)
+		return nil
+	case reflect.String:
+		if n.kind != nodeString {
+			return mismatch(n, v)
+		}
+		v.SetString(n.text)
+		return nil
+	case reflect.Slice, reflect.Array:
+		return decodeList(n, v)
+	case reflect.Map:
+		return decodeMap(n, v)
+	case reflect.Struct:
+		return decodeStruct(n, v)
+	}
+
+	// Funcs, channels and unsafe pointers cannot be restored
+	v.SetZero()
+	return nil
+}
+
+func mismatch(n *node, v reflect.Value) error {
+	return n.tok.errorf("cannot decode %s into %s", n.tok, v.Type())
+}
+
+func parseFloat(text string, bits int) (float64, error) {
+	switch text {
+	case "NaN":
+		return math.NaN(), nil
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(text, bits)
+}
+
+func parseComplex(n *node, bits int) (complex128, error) {
+	switch n.kind {
+	case nodeNumber:
+		f, err := parseFloat(n.text, bits)
+		if err != nil {
+			return 0, n.tok.errorf("invalid complex %s", n.text)
+		}
+		return complex(f, 0), nil
+	case nodeComplex:
+		re, err := parseFloat(n.text, bits)
+		if err != nil {
+			return 0, n.tok.errorf("invalid complex %s", n.text)
+		}
+		im, err := parseFloat(n.imag, bits)
+		if err != nil {
+			return 0, n.tok.errorf("invalid complex %s", n.imag)
+		}
+		return complex(re, im), nil
+	}
+	return 0, n.tok.errorf("cannot decode %s into a complex number", n.tok)
+}
+
+func decodeList(n *node, v reflect.Value) error {
+	isBytes := v.Type().Elem().Kind() == reflect.Uint8
+	var items []*node
+	switch {
+	case n.kind == nodeList:
+		items = n.items
+	case n.kind == nodeComposite && len(n.entries) == 0:
+	case n.kind == nodeBytes && isBytes:
+		data, err := hex.DecodeString(n.text)
+		if err != nil {
+			return n.tok.errorf("invalid hex bytes: %v", err)
+		}
+		if v.Kind() == reflect.Array {
+			if len(data) != v.Len() {
+				return n.tok.errorf("expected %d bytes, found %d", v.Len(), len(data))
+			}
+			reflect.Copy(v, reflect.ValueOf(data))
+			return nil
+		}
+		v.SetBytes(data)
+		return nil
+	case n.kind == nodeString && isBytes && v.Kind() == reflect.Slice:
+		v.SetBytes([]byte(n.text))
+		return nil
+	default:
+		return mismatch(n, v)
+	}
+
+	if v.Kind() == reflect.Array {
+		if len(items) != v.Len() {
+			return n.tok.errorf("expected %d items for %s, found %d", v.Len(), v.Type(), len(items))
+		}
+	} else {
+		v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+	}
+	for i, item := range items {
+		if err := decode(item, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeMap(n *node, v reflect.Value) error {
+	if n.kind != nodeComposite {
+		return mismatch(n, v)
+	}
+	typ := v.Type()
+	m := reflect.MakeMapWithSize(typ, len(n.entries))
+	for _, e := range n.entries {
+		key := reflect.New(typ.Key()).Elem()
+		if e.key != nil {
+			if err := decode(e.key, key); err != nil {
+				return err
+			}
+		} else if key.Kind() == reflect.String {
+			key.SetString(e.field)
+		} else {
+			return e.value.tok.errorf("unexpected field name %s in %s", e.field, typ)
+		}
+
+		elem := reflect.New(typ.Elem()).Elem()
+		if err := decode(e.value, elem); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, elem)
+	}
+	v.Set(m)
+	return nil
+}
+
+func decodeStruct(n *node, v reflect.Value) error {
+	typ := v.Type()
+	switch n.kind {
+	case nodeList:
+		// Positional struct literal
+		if len(n.items) != v.NumField() {
+			return n.tok.errorf("expected %d fields for %s, found %d", v.NumField(), typ, len(n.items))
+		}
+		for i, item := range n.items {
+			if err := decode(item, settable(v.Field(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nodeComposite:
+	default:
+		return mismatch(n, v)
+	}
+
+	v.SetZero()
+	for _, e := range n.entries {
+		name := e.field
+		if e.key != nil {
+			if e.key.kind != nodeString {
+				return e.key.tok.errorf("expected a field name of %s, found %s", typ, e.key.tok)
+			}
+			name = e.key.text
+		}
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			return e.value.tok.errorf("%s has no field %s", typ, name)
+		}
+		if err := decode(e.value, settable(v.FieldByIndex(field.Index))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settable makes an unexported field of an addressable struct settable.
+func settable(field reflect.Value) reflect.Value {
+	if field.CanSet() || !field.CanAddr() {
+		return field
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
+
+// generic decodes n into the value that best describes it when the target
+// is an empty interface.
+func generic(n *node) (any, error) {
+	switch n.kind {
+	case nodeBool:
+		return n.text == "true", nil
+	case nodeNumber:
+		if i, err := strconv.ParseInt(n.text, 0, 0); err == nil {
+			return int(i), nil
+		}
+		if u, err := strconv.ParseUint(n.text, 0, 64); err == nil {
+			return u, nil
+		}
+		f, err := parseFloat(n.text, 64)
+		if err != nil {
+			return nil, n.tok.errorf("invalid number %s", n.text)
+		}
+		return f, nil
+	case nodeComplex:
+		return parseComplex(n, 64)
+	case nodeString:
+		return n.text, nil
+	case nodeBytes:
+		data, err := hex.DecodeString(n.text)
+		if err != nil {
+			return nil, n.tok.errorf("invalid hex bytes: %v", err)
+		}
+		return data, nil
+	case nodeList:
+		items := make([]any, len(n.items))
+		for i, item := range n.items {
+			g, err := generic(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = g
+		}
+		return items, nil
+	case nodeComposite:
+		return genericMap(n)
+	case nodeElided:
+		return nil, n.tok.errorf("value was elided by the depth limit")
+	}
+	return nil, nil
+}
+
+// genericMap decodes struct literals into map[string]any and maps into
+// map[any]any.
+func genericMap(n *node) (any, error) {
+	fields := len(n.entries) > 0
+	for _, e := range n.entries {
+		fields = fields && e.key == nil
+	}
+
+	if fields {
+		m := make(map[string]any, len(n.entries))
+		for _, e := range n.entries {
+			g, err := generic(e.value)
+			if err != nil {
+				return nil, err
+			}
+			m[e.field] = g
+		}
+		return m, nil
+	}
+
+	m := make(map[any]any, len(n.entries))
+	for _, e := range n.entries {
+		var key any = e.field
+		if e.key != nil {
+			k, err := generic(e.key)
+			if err != nil {
+				return nil, err
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, e.key.tok.errorf("map key %s is not comparable", e.key.tok)
+			}
+			key = k
+		}
+		g, err := generic(e.value)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = g
+	}
+	return m, nil
+}
@@ -0,0 +1,121 @@
+package pprint
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type parseSample struct {
+	Name   string
+	Count  int64
+	Ratio  float64
+	Tags   []string
+	Attrs  map[string]int
+	Data   []byte
+	Next   *parseSample
+	hidden int
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	sample := parseSample{
+		Name:  "a fairly long name that needs to be wrapped over several lines when narrow",
+		Count: 1234567,
+		Ratio: 0.25,
+		Tags:  []string{"x", "y"},
+		Attrs: map[string]int{"one": 1, "two": 2},
+		Data:  []byte(strings.Repeat("\x0f\x14\x1e\x28\x32\x64", 8)),
+		Next:  &parseSample{Name: "inner", Count: -3, Tags: []string{}, Attrs: map[string]int{}, Data: []byte{}},
+	}
+
+	cases := []struct {
+		name  string
+		value any
+		opts  []Option
+	}{
+		{"scalars", []any{1, "x", 2.5, true, nil}, nil},
+		{"map", map[string][]int{"a": {1, 2}, "b": {3}}, []Option{Width(10), SortMaps()}},
+		{"single item", []int{7}, nil},
+		{"struct", sample, []Option{Width(30)}},
+		{"struct wide", sample, []Option{Width(1000)}},
+		{"struct compact", sample, []Option{Width(40), Compact()}},
+		{"pointer", &sample, []Option{Width(40)}},
+		{"go syntax", sample, []Option{GoSyntax(), Width(40)}},
+		{"go syntax pointer", &sample.Next.Count, []Option{GoSyntax()}},
+		{"underscores", []int64{1_000_000, -42}, []Option{UnderscoreNumbers()}},
+		{"floats", []float64{math.Inf(1), 1e21, -0.5}, []Option{GoSyntax()}},
+		{"complex", []complex128{1 + 2i, -3.5i}, nil},
+		{"colors", sample, []Option{Width(40), Colors(DarkTheme)}},
+		{"bytes", [][]byte{[]byte(strings.Repeat("ab", 40))}, []Option{Width(30)}},
+	}
+	for _, c := range cases {
+		text := Sprint(c.value, c.opts...)
+		target := reflect.New(reflect.TypeOf(c.value))
+		if err := Parse(text, target.Interface()); err != nil {
+			t.Errorf("%s: parsing %s: %v", c.name, text, err)
+			continue
+		}
+		exp := c.value
+		if s, ok := exp.(parseSample); ok {
+			s.hidden = 0
+			exp = s
+		}
+		if got := target.Elem().Interface(); !reflect.DeepEqual(got, exp) {
+			t.Errorf("%s: expected %#v, got %#v from %s", c.name, exp, got, text)
+		}
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	var out any
+	text := `{"a": [1, 2.5, "x"], 3: (true,), "p": Point{X: 1, Y: <nil>}, "c": <cycle -> .["p"]>, "d>": <cycle -> .["d>"]>}`
+	if err := Parse(text, &out); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[any]any{
+		"a":  []any{1, 2.5, "x"},
+		3:    []any{true},
+		"p":  map[string]any{"X": 1, "Y": nil},
+		"c":  nil,
+		"d>": nil,
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Errorf("expected %v, got %v", exp, out)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var m map[string]int
+	var l []int
+	cases := []struct {
+		text   string
+		target any
+		line   int
+		column int
+	}{
+		{`{"a": 1,` + "\n" + ` "b": }`, &m, 2, 7},
+		{`{"a": "x"}`, &m, 1, 7},
+		{`[1, 2` + "\n", &l, 2, 1},
+		{`[1, 2] 3`, &l, 1, 8},
+		{`[1, "abc]`, &l, 1, 5},
+		{`[... 20 items]`, &l, 1, 1},
+		{`[<cycle -> .["a>"]`, &l, 1, 2},
+	}
+	for _, c := range cases {
+		err := Parse(c.text, c.target)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a ParseError, got %v", c.text, err)
+			continue
+		}
+		if perr.Line != c.line || perr.Column != c.column {
+			t.Errorf("%s: expected error at %d:%d, got %v", c.text, c.line, c.column, err)
+		}
+	}
+
+	if err := Parse(`1`, l); err == nil {
+		t.Errorf("expected an error for a non-pointer target")
+	}
+}
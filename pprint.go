@@ -6,8 +6,33 @@ import (
 )
 
 var defaultDispatchMap = make(DispatchMap)
-var builtinScalars []any
 
+// Print pretty-prints object to the stream configured by opts, os.Stdout by
+// default, followed by a newline.
+func Print(object any, opts ...Option) {
+	New(opts...).PPrint(object)
+}
+
+// Sprint returns the pretty-printed representation of object.
+func Sprint(object any, opts ...Option) string {
+	return New(opts...).PFormat(object)
+}
+
+// Diff returns the differences between a and b in unified form, using a
+// printer configured by opts.
+func Diff(a, b any, opts ...Option) string {
+	return New(opts...).Diff(a, b)
+}
+
+// Changes returns the differences between a and b, using a printer
+// configured by opts.
+func Changes(a, b any, opts ...Option) []Change {
+	return New(opts...).Changes(a, b)
+}
+
+// PPrint pretty-prints object with positional settings.
+//
+// Deprecated: use Print with options.
 func PPrint(
 	object any,
 	stream io.Writer,
@@ -22,6 +47,9 @@ func PPrint(
 	printer.PPrint(object)
 }
 
+// PFormat formats object with positional settings.
+//
+// Deprecated: use Sprint with options.
 func PFormat(
 	object any,
 	stream io.Writer,
@@ -35,6 +63,9 @@ func PFormat(
 	return printer.PFormat(object)
 }
 
+// PP is an alias for PPrint.
+//
+// Deprecated: use Print with options.
 func PP(
 	object any,
 	stream io.Writer,
@@ -44,40 +75,39 @@ func PP(
 	PPrint(object, stream, indent, width, depth, compact, sortMaps, underscoreNumbers)
 }
 
+// SafeRepr returns the one-line representation of object, with map keys
+// sorted.
 func SafeRepr(object any) any {
-	str, _, _ := PrettyPrinter{}.safeRepr(object, Context{}, 0, 0)
+	str, _, _ := New(SortMaps()).Format(object, Context{}, 0, 0)
 	return str
 }
 
-func IsReadable(object any) any {
-	_, readable, _ := PrettyPrinter{}.safeRepr(object, Context{}, 0, 0)
-	return readable
+// IsReadable reports whether the formatted representation of object can be
+// read back, which is never the case for recursive objects.
+func IsReadable(object any) bool {
+	return New().IsReadable(object)
 }
 
-func IsRecurcive(object any) any {
-	_, _, recursive := PrettyPrinter{}.safeRepr(object, Context{}, 0, 0)
-	return recursive
+// IsRecursive reports whether object refers back to itself.
+func IsRecursive(object any) bool {
+	return New().IsRecursive(object)
 }
 
-func init() {
-	defaultDispatchMap[reflect.Map] = PrettyPrinter.pprintMap
-	defaultDispatchMap[reflect.Slice] = PrettyPrinter.pprintSlice
-	defaultDispatchMap[reflect.Struct] = PrettyPrinter.pprintStruct
-	defaultDispatchMap[reflect.Pointer] = PrettyPrinter.pprintPointer
+// IsRecurcive is a misspelled alias for IsRecursive.
+//
+// Deprecated: use IsRecursive.
+func IsRecurcive(object any) bool {
+	return IsRecursive(object)
+}
 
-	builtinScalars = []any{
-		getType[bool](),
-		getType[int](),
-		getType[int8](),
-		getType[int16](),
-		getType[int32](),
-		getType[int64](),
-		getType[float32](),
-		getType[float64](),
-		getType[complex64](),
-		getType[complex128](),
-		getType[string](),
-		getType[rune](),
-		getType[byte](),
-	}
+func init() {
+	defaultDispatchMap[reflect.Map] = (*PrettyPrinter).pprintMap
+	defaultDispatchMap[reflect.Slice] = (*PrettyPrinter).pprintSlice
+	defaultDispatchMap[reflect.Array] = (*PrettyPrinter).pprintSlice
+	defaultDispatchMap[reflect.Struct] = (*PrettyPrinter).pprintStruct
+	defaultDispatchMap[reflect.Pointer] = (*PrettyPrinter).pprintPointer
+	defaultDispatchMap[reflect.String] = (*PrettyPrinter).pprintString
+	defaultDispatchMap[reflect.Func] = (*PrettyPrinter).pprintFunc
+	defaultDispatchMap[reflect.Chan] = (*PrettyPrinter).pprintChan
+	defaultDispatchMap[reflect.UnsafePointer] = (*PrettyPrinter).pprintUnsafePointer
 }
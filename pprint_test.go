@@ -1,11 +1,22 @@
 package pprint
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go/parser"
+	"math"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 type sampleType struct {
@@ -116,41 +127,40 @@ func TestPPrintMap(t *testing.T) {
 		"21":     22,
 		"55551":  22,
 	}
-	// 	exp := `{1: 11,
-	//  2: 22,
-	//  2: 22,
-	//  sdas1: 22,
-	//  Sdas: 22,
-	//  asad: 22,
-	//  sadsa1: 22,
-	//  21: 22,
-	//  sdas: 22,
-	//  3: 22,
-	//  asad1: 22,
-	//  31: 22,
-	//  sdddd: 22,
-	//  sadsa: 22,
-	//  5555: 22,
-	//  55551: 22}`
-	// 	if out := PFormat(m, nil, 1, 80, 2, false, true, false); out != exp {
-	// 		t.Errorf("expected 1, got %s", out)
-	// 	} else {
-	// 		PPrint(m, nil, 1, 80, 2, false, true, false)
-	// 	}
-	PPrint(m, nil, 1, 80, 2, false, true, false)
+	exp := `{2: 22,
+ "1": 11,
+ "2": 22,
+ "21": 22,
+ "3": 22,
+ "31": 22,
+ "5555": 22,
+ "55551": 22,
+ "Sdas": 22,
+ "asad": 22,
+ "asad1": 22,
+ "sadsa": 22,
+ "sadsa1": 22,
+ "sdas": 22,
+ "sdas1": 22,
+ "sdddd": 22}`
+	if out := PFormat(m, nil, 1, 80, 2, false, true, false); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	} else {
+		PPrint(m, nil, 1, 80, 2, false, true, false)
+	}
 }
 
 func TestPPrintStruct(t *testing.T) {
 
 	sT := createSampleType("sample_text", nil)
-	exp := `sampleType(
-  F1=1,
-  F2="sample_text2",
-  F3="sample_text3",
-  F4="sample_text4",
-  F5=<nil>,
-  private=<InaccessibleField>
-)`
+	exp := `sampleType{
+  F1: 1,
+  F2: "sample_text2",
+  F3: "sample_text3",
+  F4: "sample_text4",
+  F5: <nil>,
+  private: <InaccessibleField>,
+}`
 	if out := PFormat(sT, nil, 1, 80, 2, false, true, false); out != exp {
 		t.Errorf("expected %s, got %s", exp, out)
 	} else {
@@ -164,21 +174,21 @@ func TestPPrintStructPtr(t *testing.T) {
 
 	sT := createSampleType("sample_text", sT2ptr)
 	sTptr := &sT
-	exp := fmt.Sprintf(`(*pprint.sampleType=%p)&sampleType(
-   F1=1,
-   F2="sample_text2",
-   F3="sample_text3",
-   F4="sample_text4",
-   F5=(*pprint.sampleType=%p)&sampleType(
-         F1=1,
-         F2="sample_text2",
-         F3="sample_text3",
-         F4="sample_text4",
-         F5=<nil>,
-         private=<InaccessibleField>
-       ),
-   private=<InaccessibleField>
- )`, &sT, &sT2)
+	exp := fmt.Sprintf(`(*pprint.sampleType=%p)&sampleType{
+  F1: 1,
+  F2: "sample_text2",
+  F3: "sample_text3",
+  F4: "sample_text4",
+  F5: (*pprint.sampleType=%p)&sampleType{
+        F1: 1,
+        F2: "sample_text2",
+        F3: "sample_text3",
+        F4: "sample_text4",
+        F5: <nil>,
+        private: <InaccessibleField>,
+      },
+  private: <InaccessibleField>,
+}`, &sT, &sT2)
 	if out := PFormat(sTptr, nil, 1, 80, 2, false, true, false); out != exp {
 		t.Errorf("expected %s, got %s", exp, out)
 	} else {
@@ -241,7 +251,7 @@ func TestPPrintFormat(t *testing.T) {
 		os.Stdout, 1, 80, 2, false, true, false,
 	)
 
-	pp := ppi.(PrettyPrinter)
+	pp := ppi.(*PrettyPrinter)
 
 	if err != nil {
 		t.Error(err)
@@ -293,6 +303,27 @@ func TestRepr(t *testing.T) {
 
 }
 
+func TestSafeRepr(t *testing.T) {
+	cases := []struct {
+		value any
+		exp   string
+	}{
+		{map[string]int{"b": 1, "a": 2}, `{"a": 2, "b": 1}`},
+		{[]int{1, 2}, `[1, 2]`},
+		{[]any{"x", map[int]bool{1: true}}, `["x", {1: true}]`},
+	}
+	for _, c := range cases {
+		if out := SafeRepr(c.value); out != c.exp {
+			t.Errorf("expected %s, got %s", c.exp, out)
+		}
+	}
+
+	// The zero printer uses the built-in printers too
+	if out, _, _ := (&PrettyPrinter{}).Format([]int{1, 2}, nil, 0, 0); out != `[1, 2]` {
+		t.Errorf("expected [1, 2], got %s", out)
+	}
+}
+
 func TestJsonify(t *testing.T) {
 	data := map[string]interface{}{
 		"user": map[string]interface{}{
@@ -425,15 +456,61 @@ func TestMarshalizer(t *testing.T) {
 	mr := NewMarshalizer(true, false, false, true)
 	jsonBytes, err := mr.Serialize(data)
 	if err != nil {
-		fmt.Println("Error marshalling to JSON:", err)
-		return
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatal(err)
 	}
 
-	// Print JSON string
-	fmt.Println(string(jsonBytes))
+	user := decoded["user"].(map[string]any)
+	if exp := []any{"reading", "gaming", map[string]any{"outdoor": "cycling"}}; !reflect.DeepEqual(user["hobbies"], exp) {
+		t.Errorf("expected %v, got %v", exp, user["hobbies"])
+	}
+	if exp := []any{5.0, 6.0}; !reflect.DeepEqual(decoded["0"], exp) {
+		t.Errorf("expected %v, got %v", exp, decoded["0"])
+	}
+
+	// Functions are described by their name and signature
+	stats := decoded["stats"].(map[string]any)
+	for _, c := range []struct{ got, exp any }{
+		{stats["F"], "pprint.TestMarshalizer.func1 func(interface{}, int)"},
+		{decoded["stats2"].(map[string]any)["F"], "pprint.TempFunc func(interface{}, int) string"},
+		{decoded["stats3"].(map[string]any)["F"], "pprint.TestMarshalizer.func2 func(interface{}, int) (string, error)"},
+	} {
+		if c.got != c.exp {
+			t.Errorf("expected %v, got %v", c.exp, c.got)
+		}
+	}
+
+	// Pointers carry their type next to the fields of the value
+	p := stats["P"].(map[string]any)
+	if typ := p["*"].(map[string]any)["type"]; typ != "*pprint.sampleType" {
+		t.Errorf("expected *pprint.sampleType, got %v", typ)
+	}
+	if private := p["private"]; private != "[Private Field]" {
+		t.Errorf("expected [Private Field], got %v", private)
+	}
+	inner := p["F5"].(map[string]any)["F5"].(map[string]any)
+	if value := inner["_value"]; value != 10.0 {
+		t.Errorf("expected 10, got %v", value)
+	}
 
-	PPrint(data, nil, 1, 80, 5, false, true, false)
+	// The same pointer through an interface has the same address
+	registry := decoded["marshalizer"].(map[string]any)["*"].(map[string]any)
+	registry2 := decoded["marshalizer2"].(map[string]any)["*"].(map[string]any)
+	if registry["address"] != registry2["address"] {
+		t.Errorf("expected the same address, got %v and %v", registry["address"], registry2["address"])
+	}
+	methods := registry["implements"].(map[string]any)["pprint.SerializerRegistryInterface"].([]any)
+	if !slices.Contains(methods, any("AddKind func(pprint.Serializer)")) {
+		t.Errorf("expected AddKind among %v", methods)
+	}
 
+	out := PFormat(data, nil, 1, 80, 5, false, true, false)
+	if exp := `"stats2": {F: pprint.TempFunc func(interface{}, int) string},`; !strings.Contains(out, exp) {
+		t.Errorf("expected %s in %s", exp, out)
+	}
 }
 
 func TestMarshalizerRecursion(t *testing.T) {
@@ -451,25 +528,1078 @@ func TestMarshalizerRecursion(t *testing.T) {
 		},
 	}
 	mr := NewMarshalizer(true, false, false, true)
-	mr.Serialize(data)
+	first, err := mr.Serialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	// context := mr.(*Marshalizer).context
-	// fmt.Println(context)
+	// The context is cleared between calls
+	jsonBytes, err := mr.Serialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, jsonBytes) {
+		t.Errorf("expected the same output twice, got %s and %s", first, jsonBytes)
+	}
 
-	// var prev MarshalizerContext = make(MarshalizerContext)
-	// for key, val := range context {
-	// 	if _, exists := prev[key]; exists {
-	// 		fmt.Println("Detected recursion", key, val)
-	// 	}
-	// 	prev[key] = val
-	// }
+	var decoded map[string]any
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	item1 := decoded["item1"].(map[string]any)
+	item2 := item1["item2"].(map[string]any)
+	if exp := `<cycle -> .["item1"]["item2"]>`; item2["F5"] != exp {
+		t.Errorf("expected %s, got %v", exp, item2["F5"])
+	}
+	if item2["F2"] != "12" || item2["private"] != "[Private Field]" {
+		t.Errorf("expected the fields of the sample, got %v", item2)
+	}
+	if exp := map[string]any{"item4": 5.0}; !reflect.DeepEqual(item1["item3"], exp) {
+		t.Errorf("expected %v, got %v", exp, item1["item3"])
+	}
+}
 
-	jsonBytes, err := mr.Serialize(data)
+func TestOptions(t *testing.T) {
+	l := []any{1, "sample text", true, 111111, 2222222}
+
+	ppi, err := NewPrettyPrinter(nil, 2, 20, 2, false, true, false)
 	if err != nil {
-		fmt.Println("Error marshalling to JSON:", err)
-		return
+		t.Fatal(err)
 	}
+	exp := ppi.PFormat(l)
 
-	fmt.Println(string(jsonBytes))
+	pp := New(Indent(2), Width(20), Depth(2), SortMaps())
+	if out := pp.PFormat(l); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	if out := Sprint(l, Indent(2), Width(20), Depth(2), SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	wide := pp.With(Width(80))
+	exp = `[1, "sample text", true, 111111, 2222222]`
+	if out := wide.PFormat(l); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	if pp.width != 20 {
+		t.Errorf("With modified the original printer: width %d", pp.width)
+	}
+
+	var sb strings.Builder
+	Print(l, Output(&sb))
+	if out := sb.String(); out != exp+"\n" {
+		t.Errorf("expected %s, got %s", exp+"\n", out)
+	}
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for negative indent")
+		}
+	}()
+	New(Indent(-1))
+}
+
+func TestPPrintTypedMap(t *testing.T) {
+	m := map[string]int{
+		"alpha":   1,
+		"beta":    22,
+		"gamma":   333,
+		"delta":   4444,
+		"epsilon": 55555,
+	}
+	exp := `{"alpha": 1,
+ "beta": 22,
+ "delta": 4444,
+ "epsilon": 55555,
+ "gamma": 333}`
+	if out := Sprint(m, Width(30), SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	nested := map[int]map[string]float64{
+		1: {"x": 0.5, "y": 1.5},
+		2: {"x": 2.5, "y": 3.5},
+	}
+	exp = `{1: {"x": 0.5, "y": 1.5},
+ 2: {"x": 2.5, "y": 3.5}}`
+	if out := Sprint(nested, Width(30), SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// NaN keys keep their values
+	floats := map[float64]int{math.NaN(): 1, 0.5: 2}
+	if out, exp := Sprint(floats, SortMaps()), `{NaN: 1, 0.5: 2}`; out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	if out, exp := Sprint(map[any]any{math.NaN(): "x"}), `{NaN: "x"}`; out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestPPrintTypedSlice(t *testing.T) {
+	s := []string{"alpha", "beta", "gamma", "delta"}
+	exp := `["alpha",
+ "beta",
+ "gamma",
+ "delta"]`
+	if out := Sprint(s, Width(20)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	a := [4]int{1000, 2000, 3000, 4000}
+	exp = `[1000, 2000, 3000, 4000]`
+	if out := Sprint(a); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	exp = `[1000,
+ 2000,
+ 3000,
+ 4000]`
+	if out := Sprint(a, Width(10)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	n := []int{1, 22, 333, 4444, 55555, 666666, 7777777}
+	exp = `[1, 22, 333, 4444,
+ 55555, 666666,
+ 7777777]`
+	if out := Sprint(n, Width(20), Compact()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestSortMapKeys(t *testing.T) {
+	ints := map[int]string{10: "a", 2: "b", 1: "c", -5: "d"}
+	exp := `{-5: "d", 1: "c", 2: "b", 10: "a"}`
+	if out := Sprint(ints, SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	mixed := map[any]int{"b": 1, 2.5: 2, 1: 3, true: 4, "a": 5, int64(1): 6, false: 7}
+	exp = `{false: 7, true: 4, 1: 3, 1: 6, 2.5: 2, "a": 5, "b": 1}`
+	if out := Sprint(mixed, SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Named types sort with the class of their kind, in the same order on
+	// every run
+	type B bool
+	named := map[any]int{B(false): 1, true: 2, 1: 3, B(true): 4, false: 5, 0: 6}
+	exp = `{false: 5, B(false): 1, true: 2, B(true): 4, 0: 6, 1: 3}`
+	for i := 0; i < 5; i++ {
+		if out := Sprint(named, SortMaps(), NamedTypes()); out != exp {
+			t.Fatalf("expected %s, got %s", exp, out)
+		}
+	}
+
+	type point struct{ X, Y int }
+	points := map[point]int{{2, 1}: 1, {1, 2}: 2, {1, 1}: 3}
+	keys := reflect.ValueOf(points).MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return newSafeKey(keys[i]).lessThan(newSafeKey(keys[j])) })
+	for i, want := range []point{{1, 1}, {1, 2}, {2, 1}} {
+		if got := keys[i].Interface(); got != want {
+			t.Errorf("key %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestPPrintDepth(t *testing.T) {
+	m := map[string]any{
+		"list":   []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+		"map":    map[string]int{"a": 1},
+		"struct": createSampleType("sample_text", nil),
+	}
+	exp := `{"list": [... 20 items],
+ "map": {... 1 entry},
+ "struct": sampleType{... 6 fields}}`
+	if out := Sprint(m, Width(30), Depth(1), SortMaps()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	nested := []any{[]any{[]any{[]any{1, 2, 3}}}}
+	exp = `[[[[... 3 items]]]]`
+	if out := Sprint(nested, Width(8), Depth(3)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestPFormatResult(t *testing.T) {
+	pp := New()
+
+	res := pp.PFormatResult([]int{1, 2, 3})
+	if res.Text != "[1, 2, 3]" || !res.Readable || res.Recursive || res.Cycles != nil {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	m := map[string]any{"a": 1}
+	m["self"] = m
+	res = pp.PFormatResult(m)
+	if !res.Recursive || res.Readable {
+		t.Errorf("expected recursive, unreadable result, got %+v", res)
+	}
+	if exp := []string{`.["self"]`}; !slices.Equal(res.Cycles, exp) {
+		t.Errorf("expected cycles %v, got %v", exp, res.Cycles)
+	}
+
+	sT := createSampleType("sample_text", nil)
+	sT.F5 = []any{1, &sT}
+	res = pp.PFormatResult(&sT)
+	if !res.Recursive {
+		t.Errorf("expected recursive result, got %+v", res)
+	}
+	if exp := []string{".F5[1]"}; !slices.Equal(res.Cycles, exp) {
+		t.Errorf("expected cycles %v, got %v", exp, res.Cycles)
+	}
+	if !IsRecursive(&sT) || IsReadable(&sT) {
+		t.Error("expected IsRecursive and not IsReadable")
+	}
+	if IsRecursive(createSampleType("", nil)) {
+		t.Error("expected not IsRecursive")
+	}
+}
+
+func TestPPrintLongString(t *testing.T) {
+	s := "SELECT id, name, email FROM users WHERE active = true ORDER BY name"
+	exp := `("SELECT id, name, " +
+ "email FROM users " +
+ "WHERE active = true " +
+ "ORDER BY name")`
+	if out := Sprint(s, Width(25)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	m := map[string]string{"err": "connection refused\nretrying in 5 seconds"}
+	exp = `{"err": "connection " +
+        "refused\n" +
+        "retrying in 5 " +
+        "seconds"}`
+	if out := Sprint(m, Width(30)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	if out := Sprint("short", Width(5)); out != `"short"` {
+		t.Errorf("expected %s, got %s", `"short"`, out)
+	}
+}
+
+func TestPPrintStructLayout(t *testing.T) {
+	type Point struct {
+		X, Y int
+	}
+	exp := `Point{X: 1, Y: 2}`
+	if out := Sprint(Point{1, 2}, Width(20)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	l := []Point{{1, 2}, {3, 4}}
+	exp = `[Point{X: 1, Y: 2},
+ Point{X: 3, Y: 4}]`
+	if out := Sprint(l, Width(20)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	sT := createSampleType("text", nil)
+	exp = `sampleType{
+  F1: 1, F2: "text2",
+  F3: "text3",
+  F4: "text4",
+  F5: <nil>,
+  private: <InaccessibleField>,
+}`
+	if out := Sprint(sT, Width(22), Compact()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestGoSyntax(t *testing.T) {
+	type Point struct {
+		X, Y int
+	}
+	pp := New(GoSyntax(), SortMaps(), Width(60))
+
+	cases := []struct {
+		value any
+		exp   string
+	}{
+		{map[string]int{"a": 1, "bcd": 2}, `map[string]int{"a": 1, "bcd": 2}`},
+		{[]any{1, "x", 2.0, float32(1.5), int8(3), nil}, `[]any{1, "x", 2.0, float32(1.5), int8(3), nil}`},
+		{[]Point{{1, 2}, {3, 0}}, `[]pprint.Point{{X: 1, Y: 2}, {X: 3}}`},
+		{&Point{X: 1}, `&pprint.Point{X: 1}`},
+		{[]*Point{{X: 1}}, `[]*pprint.Point{{X: 1}}`},
+		{map[float64]int{math.NaN(): 1}, `map[float64]int{float64(math.NaN()): 1}`},
+		{map[string][]int{"long key number one": {1, 2, 3}, "long key two": nil}, `map[string][]int{
+	"long key number one": {1, 2, 3},
+	"long key two":        nil,
+}`},
+	}
+	for _, c := range cases {
+		res := pp.PFormatResult(c.value)
+		if res.Text != c.exp {
+			t.Errorf("expected %s, got %s", c.exp, res.Text)
+		}
+		if !res.Readable {
+			t.Errorf("expected %s to be readable", res.Text)
+		}
+		if _, err := parser.ParseExpr(res.Text); err != nil {
+			t.Errorf("expected a Go expression, got %s: %v", res.Text, err)
+		}
+	}
+
+	x := 5
+	if out := pp.PFormat(&x); out != `&[]int{5}[0]` {
+		t.Errorf("expected %s, got %s", `&[]int{5}[0]`, out)
+	}
+
+	for _, v := range []any{make(chan int), map[string]func(){"f": func() {}}} {
+		if pp.IsReadable(v) {
+			t.Errorf("expected %T to be unreadable", v)
+		}
+	}
+
+	m := map[string]any{}
+	m["self"] = m
+	res := pp.PFormatResult(m)
+	if exp := `map[string]any{"self": (map[string]any)(nil)}`; res.Text != exp || res.Readable || !res.Recursive {
+		t.Errorf("expected unreadable %s, got %+v", exp, res)
+	}
+
+	// Unexported fields are left out unless asked for, and cannot be set
+	// from another package either way
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, c := range []struct {
+		pp  *PrettyPrinter
+		exp string
+	}{
+		{pp, `time.Time{}`},
+		{pp.With(UnexportedFields()), `time.Time{ext: 63713531045}`},
+	} {
+		if res := c.pp.PFormatResult(date); res.Text != c.exp || res.Readable {
+			t.Errorf("expected unreadable %s, got %+v", c.exp, res)
+		}
+	}
+}
+
+func TestDigitSeparator(t *testing.T) {
+	cases := []struct {
+		value any
+		sep   string
+		exp   string
+	}{
+		{1234567, "_", "1_234_567"},
+		{-1234, "_", "-1_234"},
+		{-123, "_", "-123"},
+		{int8(-128), "_", "-128"},
+		{uint64(math.MaxUint64), "_", "18_446_744_073_709_551_615"},
+		{int64(math.MinInt64), ",", "-9,223,372,036,854,775,808"},
+		{uint32(4000000000), ThinSpace, "4\u2009000\u2009000\u2009000"},
+		{1234567.25, "_", "1_234_567.25"},
+		{float32(-12345.5), ",", "-12,345.5"},
+		{1e21, "_", "1e+21"},
+		{math.Inf(-1), "_", "-Inf"},
+	}
+	for _, c := range cases {
+		if out := Sprint(c.value, DigitSeparator(c.sep)); out != c.exp {
+			t.Errorf("expected %s, got %s", c.exp, out)
+		}
+	}
+
+	exp := `[1_000, 2_000]`
+	if out := Sprint([]int{1000, 2000}, UnderscoreNumbers()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	if New(DigitSeparator(",")).IsReadable(1000) {
+		t.Errorf("expected numbers grouped with commas to be unreadable")
+	}
+
+	exp = `[]any{1_000, int64(-2_000), 1_500.5}`
+	if out := Sprint([]any{1000, int64(-2000), 1500.5}, GoSyntax(), UnderscoreNumbers()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestScalarKinds(t *testing.T) {
+	type Status int
+	type Celsius float64
+	type Label string
+
+	cases := []struct {
+		value any
+		exp   string
+		named string
+	}{
+		{uint(7), "7", "7"},
+		{uint16(65535), "65535", "65535"},
+		{uint64(math.MaxUint64), "18446744073709551615", "18446744073709551615"},
+		{uintptr(0xc000), "0xc000", "0xc000"},
+		{byte(15), "0xf", "0xf"},
+		{float32(0.1), "0.1", "0.1"},
+		{complex64(1 + 2i), "(1+2i)", "(1+2i)"},
+		{Status(3), "3", "Status(3)"},
+		{Celsius(36.6), "36.6", "Celsius(36.6)"},
+		{Label("on"), `"on"`, `Label("on")`},
+		{[]Status{1, 2}, "[1, 2]", "[Status(1), Status(2)]"},
+	}
+	for _, c := range cases {
+		if out := Sprint(c.value); out != c.exp {
+			t.Errorf("expected %s, got %s", c.exp, out)
+		}
+		if out := Sprint(c.value, NamedTypes()); out != c.named {
+			t.Errorf("expected %s, got %s", c.named, out)
+		}
+	}
 
+	exp := `Label("a long " +
+      "label")`
+	if out := Sprint(Label("a long label"), NamedTypes(), Width(20)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestColors(t *testing.T) {
+	type Status int
+	sT := createSampleType("text", nil)
+	values := []any{
+		map[string]any{"name": "John Doe", "age": 30, "ok": true, "none": nil, "tags": []string{"a", "b"}},
+		map[any]any{"1": 11, 2: 22.5, "slice": []any{1, "sample text", true, []int{111111, 2222222, 333333}}},
+		[]any{sT, &sT, []byte(strings.Repeat("data", 20)), Status(2)},
+		"a long string that is wrapped at word boundaries over several lines",
+	}
+	for _, theme := range []Theme{DarkTheme, LightTheme} {
+		for _, width := range []int{20, 40, 80} {
+			for _, v := range values {
+				opts := []Option{Width(width), SortMaps(), NamedTypes()}
+				plain := Sprint(v, opts...)
+				colored := Sprint(v, append(opts, Colors(theme))...)
+				if !strings.Contains(colored, "\x1b[") {
+					t.Errorf("expected colored output, got %s", colored)
+				}
+				if out := stripANSI(colored); out != plain {
+					t.Errorf("expected %s, got %s", plain, out)
+				}
+			}
+		}
+	}
+
+	theme := Theme{Key: "\x1b[1m", String: "\x1b[2m", Number: "\x1b[3m", Bool: "\x1b[4m"}
+	exp := "{\x1b[1m\"a\"\x1b[0m: \x1b[3m1\x1b[0m, \x1b[1m\"b\"\x1b[0m: [\x1b[2m\"x\"\x1b[0m, \x1b[4m<nil>\x1b[0m]}"
+	if out := Sprint(map[string]any{"a": 1, "b": []any{"x", nil}}, SortMaps(), Colors(theme)); out != exp {
+		t.Errorf("expected %q, got %q", exp, out)
+	}
+}
+
+// vec formats itself as a call, its fields built through the printer.
+type vec struct {
+	X, Y any
+}
+
+func (v vec) PrettyFormat(s *State) {
+	s.WriteString("Vec")
+	s.Write(s.List("(", ")", []*Doc{s.Field("X", v.X), s.Field("Y", v.Y)}))
+}
+
+// tree formats itself with its own indentation, one child per line.
+type tree struct {
+	Name     string
+	Children []*tree
+}
+
+func (n *tree) PrettyFormat(s *State) {
+	s.WriteString(n.Name)
+	children := []*Doc{}
+	for i, child := range n.Children {
+		children = append(children, Concat(Line(), s.Field(fmt.Sprintf("Children[%d]", i), child)))
+	}
+	s.Write(Nest(s.Indent(), Concat(children...)))
+}
+
+func TestFormatter(t *testing.T) {
+	exp := `[Vec(1, "a"), Vec([1, 2], {"k": 2})]`
+	values := []any{vec{1, "a"}, vec{[]int{1, 2}, map[string]int{"k": 2}}}
+	if out := Sprint(values); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	exp = `Vec("a long string",
+    ["x", "y"])`
+	if out := Sprint(vec{"a long string", []string{"x", "y"}}, Width(20)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	root := &tree{Name: "root", Children: []*tree{{Name: "a"}, {Name: "b", Children: []*tree{{Name: "c"}}}}}
+	root.Children[1].Children[0].Children = []*tree{root}
+	res := New(Indent(2), Width(10)).PFormatResult(root)
+	if !res.Recursive || res.Readable {
+		t.Errorf("expected a recursive, unreadable result, got %+v", res)
+	}
+	if exp := []string{".Children[1].Children[0].Children[0]"}; !slices.Equal(res.Cycles, exp) {
+		t.Errorf("expected cycles %v, got %v", exp, res.Cycles)
+	}
+	if !strings.HasPrefix(res.Text, "root\n  a\n  b\n    c\n      <cycle -> .>") {
+		t.Errorf("unexpected layout %s", res.Text)
+	}
+
+	// The depth limit applies to the values built through the state
+	exp = `Vec([... 1 item], {... 1 entry})`
+	if out := Sprint(vec{[]int{1}, map[int]int{1: 1}}, Depth(1)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Nil pointers are printed as nil
+	if out := Sprint((*tree)(nil)); !strings.Contains(out, "nil") {
+		t.Errorf("expected a nil pointer, got %s", out)
+	}
+}
+
+type codeError struct {
+	Code int
+}
+
+func (e *codeError) Error() string {
+	return fmt.Sprintf("code %d", e.Code)
+}
+
+func (e *codeError) String() string {
+	return "codeError"
+}
+
+func TestRegistry(t *testing.T) {
+	quote := func(s *State, object any) {
+		s.WriteString(strconv.Quote(fmt.Sprint(object)))
+	}
+	message := func(s *State, object any) {
+		s.WriteString("error(" + strconv.Quote(object.(error).Error()) + ")")
+	}
+
+	pp := New(SortMaps())
+	pp.Registry().AddType(reflect.TypeFor[time.Duration](), quote)
+	pp.Registry().AddInterface(reflect.TypeFor[error](), message)
+	pp.Registry().AddInterface(reflect.TypeFor[fmt.Stringer](), quote)
+	pp.Registry().AddKind(reflect.Int64, func(s *State, object any) {
+		s.WriteString("int64")
+	})
+
+	// Type entries win over kind entries, interfaces are matched in order
+	values := map[string]any{
+		"timeout": 2 * time.Second,
+		"count":   int64(3),
+		"err":     &codeError{Code: 7},
+		"nilErr":  (*codeError)(nil),
+		"plain":   errors.New("boom"),
+		"ok":      true,
+	}
+	exp := `{"count": int64,
+ "err": error("code 7"),
+ "nilErr": (*pprint.codeError)(nil),
+ "ok": true,
+ "plain": error("boom"),
+ "timeout": "2s"}`
+	res := pp.PFormatResult(values)
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+	if res.Readable {
+		t.Errorf("expected output of registered printers to be unreadable")
+	}
+
+	// Printers registered on a derived printer are its own
+	derived := pp.With(Width(20))
+	derived.Registry().AddKind(reflect.Bool, func(s *State, object any) {
+		s.WriteString(map[bool]string{true: "yes", false: "no"}[object.(bool)])
+	})
+	if out := derived.PFormat([]bool{true, false}); out != `[yes, no]` {
+		t.Errorf("expected [yes, no], got %s", out)
+	}
+	if out := pp.PFormat([]bool{true, false}); out != `[true, false]` {
+		t.Errorf("expected [true, false], got %s", out)
+	}
+
+	// Printers override the package defaults
+	DefaultRegistry.AddType(reflect.TypeFor[time.Duration](), func(s *State, object any) {
+		s.WriteString("duration")
+	})
+	defer DefaultRegistry.RemoveType(reflect.TypeFor[time.Duration]())
+	if out := Sprint(time.Second); out != `duration` {
+		t.Errorf("expected duration, got %s", out)
+	}
+	if out := pp.PFormat(time.Second); out != `"1s"` {
+		t.Errorf("expected \"1s\", got %s", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a type that is not an interface")
+		}
+	}()
+	pp.Registry().AddInterface(reflect.TypeFor[codeError](), quote)
+}
+
+func TestUnexportedFields(t *testing.T) {
+	type inner struct {
+		name  string
+		Score float64
+	}
+	type outer struct {
+		ID    int
+		inner inner
+		tags  []string
+		next  *outer
+	}
+	o := &outer{ID: 1, inner: inner{name: "x", Score: 0.5}, tags: []string{"a"}}
+
+	pp := New(UnexportedFields(), Width(40))
+	res := pp.PFormatResult(*o)
+	if res.Readable {
+		t.Errorf("expected private fields to be unreadable")
+	}
+	exp := `outer{
+  ID: 1,
+  ~inner: inner{~name: "x", Score: 0.5},
+  ~tags: ("a",),
+  ~next: (*pprint.outer)(nil),
+}`
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+
+	// Cycles through private fields are detected
+	o.next = o
+	if res := pp.PFormatResult(o); !res.Recursive || !slices.Equal(res.Cycles, []string{".next"}) {
+		t.Errorf("expected a cycle at .next, got %+v", res)
+	}
+
+	// The one-line form reads the same fields
+	exp = `outer{ID: 1, ~inner: inner{~name: "x", Score: 0.5}, ~tags: ("a",), ~next: (*pprint.outer)(nil)}`
+	if out, readable, _ := pp.Format(outer{ID: 1, inner: inner{name: "x", Score: 0.5}, tags: []string{"a"}}, Context{}, 0, 0); out != exp || readable {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	exp = `sampleType{F1: 1, F2: "2", F3: "3", F4: "4", F5: <nil>, ~private: 10}`
+	if out := Sprint(createSampleType("", nil), UnexportedFields(), Width(100)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	type account struct {
+		ID       int    `pprint:"name=id"`
+		Owner    string `pprint:"owner,omitempty"`
+		Flags    uint16 `pprint:",hex"`
+		Offset   int    `pprint:",hex"`
+		Quota    int64  `pprint:",bytes"`
+		Digest   []byte `pprint:",hex"`
+		Password string `pprint:",secret"`
+		Cache    any    `pprint:"-"`
+		Note     string `pprint:",omitempty"`
+	}
+	a := account{ID: 7, Flags: 0x1f, Offset: -16, Quota: 3 << 29, Digest: []uint8{0xde, 0xad}, Password: "hunter2", Cache: 1}
+
+	exp := `account{
+  id: 7,
+  Flags: 0x1f,
+  Offset: -0x10,
+  Quota: 1.5 GiB,
+  Digest: 0xdead,
+  Password: <redacted>,
+}`
+	res := New(Width(30)).PFormatResult(a)
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+	if res.Readable {
+		t.Errorf("expected renamed and hinted fields to be unreadable")
+	}
+
+	a.Owner, a.Note = "ann", "n"
+	exp = `account{id: 7, owner: "ann", Flags: 0x1f, Offset: -0x10, Quota: 1.5 GiB, Digest: 0xdead, Password: <redacted>, Note: "n"}`
+	if out, _, _ := New().Format(a, Context{}, 0, 0); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Go syntax applies the tags too, hints only where they are Go literals
+	exp = `pprint.account{id: 7, owner: "ann", Flags: 0x1f, Offset: -0x10, Quota: 1610612736, Digest: []uint8{0xde, 0xad}, Password: "" /* <redacted> */, Note: "n"}`
+	res = New(GoSyntax(), Width(200)).PFormatResult(a)
+	if res.Text != exp || res.Readable {
+		t.Errorf("expected unreadable %s, got %+v", exp, res)
+	}
+
+	// Omitting every field leaves an empty literal, hex integers read back
+	type hidden struct {
+		A int `pprint:"-"`
+		B int `pprint:",omitempty"`
+		C int `pprint:",hex"`
+	}
+	if res := New().PFormatResult(hidden{A: 1}); res.Text != `hidden{C: 0x0}` || !res.Readable {
+		t.Errorf("expected a readable hidden{C: 0x0}, got %+v", res)
+	}
+	if res := New(GoSyntax()).PFormatResult(hidden{A: 1, C: 2}); res.Text != `pprint.hidden{C: 0x2}` || !res.Readable {
+		t.Errorf("expected a readable pprint.hidden{C: 0x2}, got %+v", res)
+	}
+	if out := Sprint(struct {
+		A int `pprint:"-"`
+	}{1}); out != `{}` {
+		t.Errorf("expected {}, got %s", out)
+	}
+
+	for size, exp := range map[int64]string{0: "0 B", 1023: "1023 B", 1024: "1 KiB", 1536: "1.5 KiB", 5 << 40: "5 TiB"} {
+		if out := byteSize(float64(size)); out != exp {
+			t.Errorf("expected %s, got %s", exp, out)
+		}
+	}
+}
+
+type credentials struct {
+	Key string
+}
+
+func TestRedaction(t *testing.T) {
+	type request struct {
+		User     string
+		Password string
+		APIToken string `pprint:"token"`
+		Salt     []byte `pprint:",secret"`
+		Headers  map[string]string
+		Creds    credentials
+	}
+	req := request{
+		User:     "ann",
+		Password: "hunter2",
+		APIToken: "tok-123",
+		Salt:     []byte("pepper"),
+		Headers:  map[string]string{"Accept": "text/plain", "Authorization": "Bearer xyz"},
+		Creds:    credentials{Key: "k-456"},
+	}
+	policy := Redaction{
+		Fields: regexp.MustCompile(`(?i)password|token`),
+		Keys:   regexp.MustCompile(`(?i)^authorization$`),
+		Types:  []reflect.Type{reflect.TypeFor[credentials]()},
+	}
+	secrets := []string{"hunter2", "tok-123", "pepper", "xyz", "k-456"}
+
+	exp := `request{
+  User: "ann",
+  Password: <redacted>,
+  token: <redacted>,
+  Salt: <redacted>,
+  Headers: {"Accept": "text/plain", "Authorization": <redacted>},
+  Creds: <redacted>,
+}`
+	res := New(SortMaps(), Redact(policy)).PFormatResult(req)
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+	if res.Readable {
+		t.Errorf("expected redacted output to be unreadable")
+	}
+
+	// Secret tags apply without a policy
+	if out := Sprint(req, Width(200)); !strings.Contains(out, "Salt: <redacted>") || !strings.Contains(out, "hunter2") {
+		t.Errorf("expected only the secret field to be redacted, got %s", out)
+	}
+
+	policy.Length, policy.Hash = true, true
+	exp = `Password: <redacted len=7 sha256=f52fbd32>`
+	if out := Sprint(req, Redact(policy)); !strings.Contains(out, exp) {
+		t.Errorf("expected %s in %s", exp, out)
+	}
+
+	out := Sprint(req, Redact(policy), GoSyntax())
+	if !strings.Contains(out, `Password: "",  /* <redacted len=7 sha256=f52fbd32> */`) {
+		t.Errorf("expected a zero value and a comment, got %s", out)
+	}
+	if _, err := parser.ParseExpr(out); err != nil {
+		t.Errorf("expected a Go expression, got %v:\n%s", err, out)
+	}
+
+	mr := NewRedactingMarshalizer(policy, false, false, false, false)
+	data, err := mr.Serialize(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["Password"] != "<redacted len=7 sha256=f52fbd32>" || decoded["Salt"] != "<redacted len=6 sha256=8cbbcf29>" {
+		t.Errorf("unexpected redaction in %s", data)
+	}
+	if decoded["Headers"].(map[string]any)["Authorization"] != "<redacted len=10 sha256=cf273736>" {
+		t.Errorf("unexpected redaction in %s", data)
+	}
+
+	for _, secret := range secrets {
+		for _, text := range []string{res.Text, out, string(data)} {
+			if strings.Contains(text, secret) {
+				t.Errorf("secret %s leaked in %s", secret, text)
+			}
+		}
+	}
+	// Unexported secrets are hashed from their values, hidden ones not at all
+	type vault struct {
+		Name string
+		pin  int `pprint:",secret"`
+	}
+	v := vault{Name: "v", pin: 1234}
+	hashed := Redact(Redaction{Hash: true})
+	for _, c := range []struct {
+		opts []Option
+		exp  string
+	}{
+		{[]Option{hashed}, `vault{Name: "v", pin: <redacted>}`},
+		{[]Option{hashed, UnexportedFields()}, `vault{Name: "v", ~pin: <redacted sha256=03ac6742>}`},
+		{[]Option{hashed, UnexportedFields(), GoSyntax()}, `pprint.vault{Name: "v", pin: 0 /* <redacted sha256=03ac6742> */}`},
+	} {
+		if out := Sprint(v, c.opts...); out != c.exp {
+			t.Errorf("expected %s, got %s", c.exp, out)
+		}
+	}
+}
+
+func TestHideAddresses(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	n := &node{Name: "a", Next: &node{Name: "b"}}
+	exp := `(*pprint.node)&node{Name: "a", Next: (*pprint.node)&node{Name: "b", Next: (*pprint.node)(nil)}}`
+	res := New(HideAddresses(), Width(100)).PFormatResult(n)
+	if res.Text != exp || !res.Readable {
+		t.Errorf("expected a readable %s, got %+v", exp, res)
+	}
+	var restored *node
+	if err := Parse(res.Text, &restored); err != nil || !reflect.DeepEqual(restored, n) {
+		t.Errorf("expected %+v, got %+v, %v", n, restored, err)
+	}
+
+	n.Next.Next = n
+	exp = `(*pprint.node)&node{Name: "a", Next: (*pprint.node)&node{Name: "b", Next: <cycle -> .>}}`
+	if out := Sprint(n, HideAddresses(), Width(120)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	exp = `[(chan int)(len=0, cap=0), (func())(nil)]`
+	if out := Sprint([]any{make(chan int), (func())(nil)}, HideAddresses()); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func TestCyclePaths(t *testing.T) {
+	type user struct {
+		Name    string
+		Manager *user
+		Peers   map[string]*user
+	}
+	type org struct {
+		Users []*user
+	}
+	o := &org{}
+	for _, name := range []string{"ann", "bob", "cid", "dan"} {
+		o.Users = append(o.Users, &user{Name: name})
+	}
+	o.Users[3].Manager = o.Users[3]
+	o.Users[1].Peers = map[string]*user{"bob": o.Users[1]}
+
+	pp := New(HideAddresses(), Width(60))
+	exp := `(*pprint.org)&org{
+  Users: [(*pprint.user)&user{
+            Name: "ann",
+            Manager: (*pprint.user)(nil),
+            Peers: {},
+          },
+          (*pprint.user)&user{
+            Name: "bob",
+            Manager: (*pprint.user)(nil),
+            Peers: {"bob": <cycle -> .Users[1]>},
+          },
+          (*pprint.user)&user{
+            Name: "cid",
+            Manager: (*pprint.user)(nil),
+            Peers: {},
+          },
+          (*pprint.user)&user{
+            Name: "dan",
+            Manager: <cycle -> .Users[3]>,
+            Peers: {},
+          }],
+}`
+	res := pp.PFormatResult(o)
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+	if exp := []string{`.Users[1].Peers["bob"]`, ".Users[3].Manager"}; !slices.Equal(res.Cycles, exp) {
+		t.Errorf("expected cycles %v, got %v", exp, res.Cycles)
+	}
+
+	m := map[string]any{"a": 1}
+	m["self"] = m
+	if out := Sprint(m, SortMaps()); out != `{"a": 1, "self": <cycle -> .>}` {
+		t.Errorf("expected a cycle to the root, got %s", out)
+	}
+
+	mr := NewMarshalizer(false, false, false, false)
+	data, err := mr.Serialize(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	users := decoded["Users"].([]any)
+	if manager := users[3].(map[string]any)["Manager"]; manager != "<cycle -> .Users[3]>" {
+		t.Errorf("expected <cycle -> .Users[3]>, got %v", manager)
+	}
+	if peer := users[1].(map[string]any)["Peers"].(map[string]any)["bob"]; peer != "<cycle -> .Users[1]>" {
+		t.Errorf("expected <cycle -> .Users[1]>, got %v", peer)
+	}
+}
+
+func TestSharedRefs(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+		Tags []string
+	}
+	tags := []string{"x", "y"}
+	leaf := &node{Name: "leaf", Tags: tags}
+	loop := &node{Name: "loop"}
+	loop.Next = loop
+	nodes := []*node{{Name: "a", Next: leaf, Tags: tags}, {Name: "b", Next: leaf}, leaf, loop}
+
+	pp := New(SharedRefs(), HideAddresses(), Width(40))
+	exp := `[(*pprint.node)&node{
+   Name: "a",
+   Next: &1 = node{
+           Name: "leaf",
+           Next: (*pprint.node)(nil),
+           Tags: &2 = ["x", "y"],
+         },
+   Tags: *2,
+ },
+ (*pprint.node)&node{
+   Name: "b",
+   Next: *1,
+   Tags: [],
+ },
+ *1,
+ &3 = node{
+   Name: "loop",
+   Next: *3,
+   Tags: [],
+ }]`
+	res := pp.PFormatResult(nodes)
+	if res.Text != exp {
+		t.Errorf("expected %s, got %s", exp, res.Text)
+	}
+	if res.Readable || !res.Recursive || !slices.Equal(res.Cycles, []string{".[3].Next"}) {
+		t.Errorf("expected an unreadable result with a cycle at .[3].Next, got %+v", res)
+	}
+
+	// Values reached once are printed as without the option
+	m := map[string][]int{"a": {1, 2}}
+	if out, exp := Sprint(m, SharedRefs()), Sprint(m); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+	if out := Sprint([]any{m, m}, SharedRefs()); out != `[&1 = {"a": [1, 2]}, *1]` {
+		t.Errorf("expected the map to be labeled, got %s", out)
+	}
+	if out := Sprint([]any{m, m}, SharedRefs(), Depth(1)); out != "[{... 1 entry}, {... 1 entry}]" {
+		t.Errorf("expected placeholders past the depth limit, got %s", out)
+	}
+
+	// Distinct zero-size values share an address without aliasing
+	type empty struct {
+		C, D *struct{}
+		E, F []struct{}
+		G, H []int
+	}
+	e := empty{C: &struct{}{}, D: &struct{}{}, E: make([]struct{}, 2), F: make([]struct{}, 2), G: []int{}, H: []int{}}
+	if out, exp := Sprint(e, SharedRefs(), HideAddresses(), Width(200)), Sprint(e, HideAddresses(), Width(200)); out != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+}
+
+func signatureFunc(v any, n int) string { return "" }
+
+func TestFuncsAndInterfaces(t *testing.T) {
+	type holder struct {
+		Count any
+		Tags  any
+		Name  any
+		Err   error
+	}
+	ch := make(chan<- string, 4)
+	ch <- "a"
+	x := 1
+	values := []any{signatureFunc, ch, unsafe.Pointer(&x), unsafe.Pointer(nil), holder{Count: int64(42), Tags: []string{"a", "b"}, Name: "n"}}
+
+	exp := `[pprint.signatureFunc func(interface{}, int) string,
+ (chan<- string)(len=1, cap=4),
+ (unsafe.Pointer)(<address>),
+ (unsafe.Pointer)(nil),
+ holder{Count: int64(42), Tags: []string(["a", "b"]), Name: "n", Err: <nil>}]`
+	res := New(HideAddresses()).PFormatResult(values)
+	if res.Text != exp || res.Readable {
+		t.Errorf("expected an unreadable %s, got %+v", exp, res)
+	}
+
+	// The dynamic type does not read back, single elements keep their brackets
+	res = New().PFormatResult(holder{Count: int64(42), Tags: []int8{1}})
+	if exp := `holder{Count: int64(42), Tags: []int8([1]), Name: <nil>, Err: <nil>}`; res.Text != exp || res.Readable {
+		t.Errorf("expected an unreadable %s, got %+v", exp, res)
+	}
+	if res := New().PFormatResult(holder{Count: 42, Tags: [][]int{{1}}}); !strings.Contains(res.Text, `Tags: [][]int([(1,)])`) || res.Readable {
+		t.Errorf("expected nested single elements as tuples, got %+v", res)
+	}
+	out := Sprint(holder{Tags: []holder{{Tags: []int{1}}}}, Width(200))
+	if exp := `Tags: []pprint.holder([holder{Count: <nil>, Tags: []int([1]), Name: <nil>, Err: <nil>}])`; !strings.Contains(out, exp) {
+		t.Errorf("expected nested conversions to keep their brackets, got %s", out)
+	}
+}
+
+// benchConfig builds a config-like value with n leaf entries.
+func benchConfig(n int) any {
+	services := make([]any, 0, n/5)
+	for i := 0; i < n/5; i++ {
+		services = append(services, map[string]any{
+			"name":    fmt.Sprintf("service-%d", i),
+			"port":    8000 + i,
+			"enabled": i%2 == 0,
+			"tags":    []string{"a", "b"},
+		})
+	}
+	return map[string]any{"services": services}
+}
+
+// benchNested builds slices nested depth levels deep.
+func benchNested(depth int) any {
+	var v any = []any{1, 2, 3}
+	for i := 0; i < depth; i++ {
+		v = []any{i, v, "sibling"}
+	}
+	return v
+}
+
+func BenchmarkSprintSize(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		v := benchConfig(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Sprint(v, SortMaps())
+			}
+		})
+	}
+}
+
+func BenchmarkSprintDepth(b *testing.B) {
+	for _, depth := range []int{10, 100, 1000} {
+		v := benchNested(depth)
+		b.Run(fmt.Sprint(depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Sprint(v)
+			}
+		})
+	}
 }
@@ -0,0 +1,75 @@
+// Package pprinttest compares pretty-printed values with golden files.
+//
+// Snapshot prints a value and compares the output with testdata/NAME.golden.
+// Running the tests with -update writes the current output to the golden
+// files instead:
+//
+//	go test ./... -update
+package pprinttest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goimp/pprint"
+)
+
+var update = flag.Bool("update", false, "write pretty-printed snapshots to their golden files")
+
+// Options is the profile snapshots are printed with. Maps are sorted and
+// addresses hidden so that the output is the same on every run.
+var Options = []pprint.Option{pprint.SortMaps(), pprint.HideAddresses()}
+
+// Snapshot pretty-prints value with Options followed by opts and reports an
+// error with the differing lines if the output is not the content of
+// testdata/name.golden. With -update it writes the file instead.
+func Snapshot(t testing.TB, name string, value any, opts ...pprint.Option) {
+	t.Helper()
+	got := pprint.Sprint(value, append(slices.Clone(Options), opts...)...) + "\n"
+	path := filepath.Join("testdata", filepath.FromSlash(name)+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("pprinttest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("pprinttest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("pprinttest: %v (run with -update to create it)", err)
+	}
+	if string(want) != got {
+		t.Errorf("pprinttest: output differs from %s (run with -update to accept it):\n%s", path, diffLines(string(want), got))
+	}
+}
+
+// diffLines lists the lines of want and got that differ, marked with - and +
+// and preceded by their line number, in want for removed lines and in got
+// otherwise.
+func diffLines(want, got string) string {
+	var sb strings.Builder
+	lines := func(text string) []string {
+		return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	for _, change := range pprint.Changes(lines(want), lines(got)) {
+		index, _ := strconv.Atoi(strings.Trim(change.Path, ".[]"))
+		fmt.Fprintf(&sb, "@@ line %d @@\n", index+1)
+		if change.Kind != pprint.Added {
+			fmt.Fprintf(&sb, "- %s\n", change.Before)
+		}
+		if change.Kind != pprint.Removed {
+			fmt.Fprintf(&sb, "+ %s\n", change.After)
+		}
+	}
+	return sb.String()
+}
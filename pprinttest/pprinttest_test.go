@@ -0,0 +1,122 @@
+package pprinttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goimp/pprint"
+)
+
+type node struct {
+	Name     string
+	Labels   map[string]int
+	Parent   *node
+	Children []*node
+	OnChange func()
+}
+
+func sampleTree() *node {
+	root := &node{Name: "root", Labels: map[string]int{"zone": 3, "app": 1, "tier": 2}}
+	for _, name := range []string{"a", "b"} {
+		root.Children = append(root.Children, &node{Name: name, Parent: root, OnChange: func() {}})
+	}
+	return root
+}
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	runtime.Goexit()
+}
+
+// run calls Snapshot with a recorder, on its own goroutine so that Fatalf can
+// stop it.
+func run(t *testing.T, name string, value any, opts ...pprint.Option) *recorder {
+	r := &recorder{TB: t}
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		Snapshot(r, name, value, opts...)
+	}()
+	<-done
+	return r
+}
+
+func TestSnapshot(t *testing.T) {
+	Snapshot(t, "tree", sampleTree())
+	Snapshot(t, "nested/labels", map[string][]int{"b": {2}, "a": {1, 1}}, pprint.Width(10))
+}
+
+func TestSnapshotMismatch(t *testing.T) {
+	tree := sampleTree()
+	tree.Children[1].Name = "c"
+	tree.Labels["tier"] = 5
+
+	r := run(t, "tree", tree)
+	if len(r.errors) != 1 || r.fatal {
+		t.Fatalf("expected a single error, got %q", r.errors)
+	}
+	if !strings.Contains(r.errors[0], filepath.Join("testdata", "tree.golden")) {
+		t.Errorf("expected the golden file in %s", r.errors[0])
+	}
+	exp := `@@ line 3 @@
+-   Labels: {"app": 1, "tier": 2, "zone": 3},
++   Labels: {"app": 1, "tier": 5, "zone": 3},
+@@ line 13 @@
+-                Name: "b",
++                Name: "c",
+`
+	if !strings.HasSuffix(r.errors[0], exp) {
+		t.Errorf("expected %s, got %s", exp, r.errors[0])
+	}
+}
+
+func TestSnapshotMissing(t *testing.T) {
+	r := run(t, "missing", 1)
+	if !r.fatal || !strings.Contains(r.errors[0], "-update") {
+		t.Errorf("expected a fatal error suggesting -update, got %q", r.errors)
+	}
+}
+
+func TestSnapshotUpdate(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(dir) })
+
+	*update = true
+	func() {
+		defer func() { *update = false }()
+		Snapshot(t, "new/value", []int{1, 2})
+	}()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "new", "value.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[1, 2]\n" {
+		t.Errorf("expected [1, 2], got %s", data)
+	}
+	Snapshot(t, "new/value", []int{1, 2})
+}
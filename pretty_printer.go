@@ -7,43 +7,60 @@ import (
 	"os"
 	"reflect"
 	"regexp"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
+	"unsafe"
 )
 
 type PrettyPrinter struct {
-	stream            io.Writer
-	width             int
-	depth             int
-	indentPerLevel    int
-	compact           bool
-	sortMaps          bool
-	underscoreNumbers bool
+	stream         io.Writer
+	width          int
+	depth          int
+	indentPerLevel int
+	compact        bool
+	sortMaps       bool
+	digitSeparator string
+	namedTypes     bool
+	theme          Theme
+	goSyntax       bool
+	unexported     bool
+	redaction      *Redaction
+	hideAddresses  bool
+	sharedRefs     bool
 
 	recursive   bool
 	readable    bool
+	cycles      []string
+	path        []string
+	shared      map[sharedKey]int
+	labels      int
+	converted   int // level of a slice printed inside a conversion, +1
 	dispatchMap DispatchMap
+	registry    *Registry
+}
+
+// Result describes a single formatting call.
+type Result struct {
+	Text      string
+	Readable  bool
+	Recursive bool
+	// Cycles holds the paths, such as .F5[2], at which a reference back to
+	// an enclosing object was found.
+	Cycles []string
 }
 
 type PrettyPrinterInterface interface {
-	PPrint(object any)                                                                            // +
-	PFormat(object any) string                                                                    // +
-	IsRecursive(object any) bool                                                                  // +
-	IsReadable(object any) bool                                                                   // +
-	format(object any, stream io.Writer, indent, allowance int, context Context, level int)       // +
-	pprintMap(object any, stream io.Writer, indent, allowance int, context Context, level int)    // +
-	pprintSlice(object any, stream io.Writer, indent, allowance int, context Context, level int)  // +
-	pprintStruct(object any, stream io.Writer, indent, allowance int, context Context, level int) // +
-	pprintString(object any, stream io.Writer, indent, allowance int, context Context, level int) // +
-	pprintBytes(object any, stream io.Writer, indent, allowance int, context Context, level int)  // +
-
-	formatMapItems(items []MappingItem, stream io.Writer, indent, allowance int, context Context, level int)    // +
-	formatItems(object []any, stream io.Writer, indent, allowance int, context Context, level int)              // +
-	formatStructItems(items []StructField, stream io.Writer, indent, allowance int, context Context, level int) // +
-
-	repr(object any, context Context, level int) string                              // +
-	Format(object any, context Context, maxLevels, level int) (string, bool, bool)   // +
-	safeRepr(object any, context Context, maxLevels, level int) (string, bool, bool) // +
+	PPrint(object any)                                                             // +
+	PFormat(object any) string                                                     // +
+	IsRecursive(object any) bool                                                   // +
+	IsReadable(object any) bool                                                    // +
+	PFormatResult(object any) Result                                               // +
+	With(opts ...Option) *PrettyPrinter                                            // +
+	Doc(object any) *Doc                                                           // +
+	FormatDoc(d *Doc) string                                                       // +
+	Registry() *Registry                                                           // +
+	Format(object any, context Context, maxLevels, level int) (string, bool, bool) // +
 }
 
 func NewPrettyPrinter(
@@ -67,535 +84,712 @@ func NewPrettyPrinter(
 		stream = os.Stdout
 	}
 
+	digitSeparator := ""
+	if underscoreNumbers {
+		digitSeparator = "_"
+	}
+
 	// Return the initialized PrettyPrinter
-	return PrettyPrinter{
-		depth:             depth,
-		indentPerLevel:    indent,
-		width:             width,
-		stream:            stream,
-		compact:           compact,
-		sortMaps:          sortMaps,
-		underscoreNumbers: underscoreNumbers,
-		dispatchMap:       defaultDispatchMap,
+	return &PrettyPrinter{
+		depth:          depth,
+		indentPerLevel: indent,
+		width:          width,
+		stream:         stream,
+		compact:        compact,
+		sortMaps:       sortMaps,
+		digitSeparator: digitSeparator,
+		dispatchMap:    defaultDispatchMap,
+		registry:       NewRegistry(DefaultRegistry),
 	}, nil
 }
 
-func (pp PrettyPrinter) PPrint(object any) {
+func (pp *PrettyPrinter) PPrint(object any) {
 	if pp.stream != nil {
-		pp.format(object, pp.stream, 0, 0, nil, 0)
+		io.WriteString(pp.stream, pp.PFormat(object))
 		io.WriteString(pp.stream, "\n") // Write newline
 	}
 }
 
-func (pp PrettyPrinter) PFormat(object any) string {
-	var sio bytes.Buffer
-	pp.format(object, &sio, 0, 0, nil, 0) // Format the object into the buffer
-	return sio.String()                   // Return the formatted content as string
+func (pp *PrettyPrinter) PFormat(object any) string {
+	return pp.PFormatResult(object).Text
 }
 
-func (pp PrettyPrinter) IsRecursive(object any) bool {
-	_, _, recursive := pp.Format(object, nil, 0, 0)
-	return recursive
-}
+// PFormatResult formats object and reports whether the output is readable and
+// whether, and where, object refers back to itself.
+func (pp *PrettyPrinter) PFormatResult(object any) Result {
+	call := pp.begin(object)
 
-func (pp PrettyPrinter) IsReadable(object any) bool {
-	// Call the format method once and capture the returned values
-	_, readable, _ := pp.Format(object, nil, 0, 0)
-	return readable
+	var sio bytes.Buffer
+	if call.goSyntax {
+		io.WriteString(&sio, call.goSource(object))
+	} else {
+		call.format(object, &sio, 0, 0, nil, 0) // Format the object into the buffer
+	}
+	return Result{
+		Text:      sio.String(),
+		Readable:  call.readable,
+		Recursive: call.recursive,
+		Cycles:    call.cycles,
+	}
 }
 
-func (pp PrettyPrinter) format(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	// Get the unique id of the object (using reflect to simulate id)
-	objectId := id(object)
+// Doc returns the document that object is laid out from, to be combined
+// into a custom layout with the document functions such as Group and Nest.
+func (pp *PrettyPrinter) Doc(object any) *Doc {
+	return pp.begin(object).build(object, make(Context), 0)
+}
 
-	if context == nil {
-		context = make(Context)
+// begin returns the copy of the printer that formats object. Per-call state
+// lives on the copy so the printer can be shared.
+func (pp *PrettyPrinter) begin(object any) *PrettyPrinter {
+	call := *pp
+	call.readable = true
+	call.recursive = false
+	call.cycles = nil
+	call.path = nil
+	call.shared = nil
+	call.labels = 0
+	if call.sharedRefs && !call.goSyntax {
+		call.shared = call.findShared(object)
 	}
+	return &call
+}
 
-	if _, exists := context[objectId]; exists {
-		io.WriteString(stream, recursion(object))
-		// Recursion detected
-		pp.recursive = true
-		pp.readable = false
-		return
-	}
+// FormatDoc lays out d, breaking groups that do not fit in the width.
+func (pp *PrettyPrinter) FormatDoc(d *Doc) string {
+	var sb strings.Builder
+	layout(&sb, d, pp.width, 0, 0)
+	return sb.String()
+}
 
-	rep := pp.repr(object, context, level)
-	// Check if the representation exceeds the max width
-	maxWidth := pp.width - indent - allowance
+// Registry returns the printer's own registry, layered on DefaultRegistry,
+// to add printers used by this printer only.
+func (pp *PrettyPrinter) Registry() *Registry {
+	return pp.registry
+}
 
-	if len(rep) > maxWidth {
-		if object == nil {
-			io.WriteString(stream, rep)
-			return
-		}
-		typ := reflect.TypeOf(object)
-		p, exists := pp.dispatchMap[typ.Kind()]
-		if exists {
-			context[objectId] = 1
-			p(pp, object, stream, indent, allowance, context, level+1)
-			delete(context, objectId)
-			return
-		}
+// lookupPrinter returns the registered printer for value, or a printer that
+// calls its PrettyFormat method.
+func (pp *PrettyPrinter) lookupPrinter(value reflect.Value) (Printer, bool) {
+	registry := pp.registry
+	if registry == nil {
+		registry = DefaultRegistry
 	}
+	return registry.lookup(value)
+}
 
-	// Write the normal representation to the stream
-	io.WriteString(stream, rep)
+func (pp *PrettyPrinter) IsRecursive(object any) bool {
+	return pp.PFormatResult(object).Recursive
 }
 
-func (pp PrettyPrinter) pprintPointer(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	// objectId := id(object)
-	// typ := reflect.TypeOf(object)
-	value := reflect.ValueOf(object)
-	if value.Kind() == reflect.Pointer {
-		pointerPrefix := fmt.Sprintf("(%T=%p)&", object, object)
-		io.WriteString(stream, pointerPrefix)
-		intf := reflect.Indirect(value).Interface()
-		// indent += len(pointerPrefix)
-		indent += 1
-		pp.format(intf, stream, indent, allowance, context, level)
-	}
+func (pp *PrettyPrinter) IsReadable(object any) bool {
+	return pp.PFormatResult(object).Readable
 }
 
-func (pp PrettyPrinter) pprintMap(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	io.WriteString(stream, "{")
-	if pp.indentPerLevel > 1 {
-		io.WriteString(stream, strings.Repeat(" ", pp.indentPerLevel-1))
-	}
+// pushPath and popPath maintain the access path of the value being formatted.
+func (pp *PrettyPrinter) pushPath(segment string) {
+	pp.path = append(pp.path, segment)
+}
 
-	if mapping, ok := object.(map[any]any); ok {
-		length := len(mapping)
-		if length > 0 {
+func (pp *PrettyPrinter) popPath() {
+	pp.path = pp.path[:len(pp.path)-1]
+}
 
-			var items []MappingItem
+// pathPrefix returns the access path of the enclosing value whose path has n
+// segments. Paths are only joined when a cycle is found.
+func (pp *PrettyPrinter) pathPrefix(n int) string {
+	return formatPath(pp.path[:min(n, len(pp.path))])
+}
 
-			for key, value := range mapping {
-				items = append(items, MappingItem{Key: key, Entry: value})
-			}
+// markCycle records that a reference to an enclosing object was found at the
+// current path.
+func (pp *PrettyPrinter) markCycle() {
+	pp.recursive = true
+	pp.readable = false
+	path := formatPath(pp.path)
+	if !slices.Contains(pp.cycles, path) {
+		pp.cycles = append(pp.cycles, path)
+	}
+}
 
-			// FIXME:
-			// if pp.sortMaps {
-			// 	items = sort.Slice(mapping, safeKey)
-			// }
-			pp.formatMapItems(items, stream, indent, allowance+1, context, level)
-		}
+// format lays out object starting at column indent, leaving room for
+// allowance columns of text that the caller writes after it.
+func (pp *PrettyPrinter) format(object any, stream io.Writer, indent, allowance int, context Context, level int) {
+	if context == nil {
+		context = make(Context)
 	}
 
-	io.WriteString(stream, "}")
+	var sb strings.Builder
+	layout(&sb, pp.build(object, context, level), pp.width, indent, allowance)
+	io.WriteString(stream, sb.String())
 }
 
-func (pp PrettyPrinter) formatMapItems(items []MappingItem, stream io.Writer, indent, allowance int, context Context, level int) {
-	indent += pp.indentPerLevel
-	delimnl := ",\n" + strings.Repeat(" ", indent)
-	lastIndex := len(items) - 1
-	for i, item := range items {
-		last := i == lastIndex
-		rep := pp.repr(item.Key, context, level)
-		io.WriteString(stream, rep)
-		io.WriteString(stream, ": ")
-
-		pp.format(item.Entry, stream, indent+len(rep)+2, allowance, context, level)
-		if !last {
-			io.WriteString(stream, delimnl)
+// build renders object into a document once, recording readability,
+// recursion and cycles along the way. Line breaks are chosen later, when the
+// document is laid out.
+func (pp *PrettyPrinter) build(object any, context Context, level int) *Doc {
+	if pp.shared != nil {
+		if doc, ok := pp.buildShared(object, context, level); ok {
+			return doc
 		}
 	}
+	return pp.buildValue(object, context, level)
 }
 
-func (pp PrettyPrinter) pprintSlice(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	// Wrap []byte
-	if slice, ok := object.([]byte); ok {
-		pp.pprintBytes(slice, stream, indent, allowance, context, level)
-		return
+// buildValue builds object without labeling it as a shared reference.
+func (pp *PrettyPrinter) buildValue(object any, context Context, level int) *Doc {
+	if object == nil {
+		pp.readable = false
+		return Text(paint(pp.theme.Bool, repr(object)))
 	}
 
-	// Write the opening bracket for the slice
-	io.WriteString(stream, "[")
-	// Ensure the object is a slice, then call formatItems to handle the items
+	if field, ok := object.(InaccessibleField); ok {
+		pp.readable = false
+		return Text(paint(pp.theme.Marker, field.String()))
+	}
 
-	if slice, ok := object.([]any); ok {
-		pp.formatItems(slice, stream, indent, allowance+1, context, level)
+	value := reflect.ValueOf(object)
+	if pp.redaction.value(value) {
+		return pp.redacted(object)
 	}
-	// Write the closing bracket for the slice
-	io.WriteString(stream, "]")
-}
 
-func (pp PrettyPrinter) formatItems(items []any, stream io.Writer, indent, allowance int, context Context, level int) {
-	// Increase indent for the next level
-	indent += pp.indentPerLevel
-	if pp.indentPerLevel > 1 {
-		io.WriteString(stream, fmt.Sprintf("%*s", pp.indentPerLevel-1, ""))
+	// Handle the basic kinds (bool, numbers) and named types of them, strings
+	// are dispatched to be wrapped
+	printer, isCustom := pp.lookupPrinter(value)
+	if value.Kind() != reflect.String && !isCustom {
+		if rep, readable, ok := pp.scalarRepr(value); ok {
+			pp.readable = pp.readable && readable
+			return Text(rep)
+		}
 	}
 
-	delimnl := ",\n" + fmt.Sprintf("%*s", indent, "")
-	delim := ""
-	width := pp.width - indent + 1
-	maxWidth := width
+	objectId := id(object)
 
-	for i, ent := range items {
-		// Check if it's the last item
-		last := i == len(items)-1
-		if last {
-			maxWidth -= allowance
-			width -= allowance
+	// Past the depth limit containers are replaced by placeholders
+	if pp.depth > 0 && level >= pp.depth && isContainer(object) && size(value) > 0 {
+		pp.readable = false
+		if context.Contains(objectId) {
+			pp.recursive = true
 		}
+		return Text(paint(pp.theme.Marker, elided(value)))
+	}
 
-		if pp.compact {
-			rep := pp.repr(ent, context, level)
-			w := len(rep) + 2
-			if width < w {
-				width = maxWidth
-				if delim != "" {
-					delim = delimnl
-				}
-			}
-			if width >= w {
-				width -= w
-				io.WriteString(stream, delim)
-				delim = ", "
-				io.WriteString(stream, rep)
-				continue
-			}
-		}
+	// Prevent infinite recursion
+	if context.Contains(objectId) {
+		pp.markCycle()
+		return Text(paint(pp.theme.Marker, cycleMarker(pp.pathPrefix(context[objectId]))))
+	}
 
-		io.WriteString(stream, delim)
-		delim = delimnl
-		pp.format(ent, stream, indent, allowance, context, level)
+	// Registered printers and types that format themselves come before the
+	// built-in printers
+	if isCustom {
+		context[objectId] = len(pp.path)
+		defer delete(context, objectId)
+		return pp.pprintCustom(printer, object, context, level)
+	}
+
+	// The zero PrettyPrinter uses the built-in printers
+	dispatchMap := pp.dispatchMap
+	if dispatchMap == nil {
+		dispatchMap = defaultDispatchMap
+	}
+	if p, exists := dispatchMap[value.Kind()]; exists {
+		context[objectId] = len(pp.path)
+		defer delete(context, objectId)
+		return p(pp, object, context, level)
 	}
+
+	return Text(repr(object))
 }
 
-func (pp PrettyPrinter) pprintStruct(object any, stream io.Writer, indent, allowance int, context Context, level int) {
+// bracketed lays out items between open and close, on one line when they
+// fit and otherwise hanging one per line, or as many per line as fit when
+// compact, after open.
+func (pp *PrettyPrinter) bracketed(open, close *Doc, items []*Doc, compact bool) *Doc {
+	sep := Concat(Text(","), Line())
+	body := Join(sep, items)
+	if compact {
+		body = Fill(sep, items)
+	}
+	pad := Concat()
+	if pp.indentPerLevel > 1 {
+		pad = IfBreak(Text(strings.Repeat(" ", pp.indentPerLevel-1)), nil)
+	}
+	return Group(Align(Concat(open, pad, Nest(pp.indentPerLevel, body), close)))
+}
 
+func (pp *PrettyPrinter) pprintPointer(object any, context Context, level int) *Doc {
 	value := reflect.ValueOf(object)
+	if value.IsNil() {
+		return Text(pp.nilPointer(object))
+	}
 
-	if value.Kind() == reflect.Struct {
-		// Get the name of the struct
-		typ := reflect.TypeOf(object)
-		structName := typ.Name()
-
-		var items []StructField
-
-		// Now you can process the fields of the struct
-		for i := 0; i < value.NumField(); i++ {
-			field := value.Field(i)
-			fieldName := typ.Field(i).Name
-			// fmt.Println("isValid", field.IsValid(), "canSet", field.CanSet(), "canInterface", field.CanInterface())
-			// if field.IsValid() && field.CanSet() {
-			if field.IsValid() && field.CanInterface() {
-				items = append(items, StructField{
-					Name:  fieldName,
-					Entry: field.Interface(), // Access the field as interface{}
-				})
-			} else {
-				items = append(items, StructField{
-					Name:  fieldName,
-					Entry: InaccessibleField{},
-				})
-			}
-		}
+	// Dereferencing does not count as a nesting level
+	prefix := Text(pp.pointerPrefix(object))
+	elem := pp.build(value.Elem().Interface(), context, level)
+
+	// Items of maps and slices hang after the prefix, struct fields are
+	// indented relative to where the pointer starts
+	if value.Elem().Kind() == reflect.Struct && elem.kind == docAlign {
+		return Align(Concat(prefix, elem.docs[0]))
+	}
+	return Concat(prefix, elem)
+}
 
-		io.WriteString(stream, structName+"(\n")
-		pp.formatStructItems(items, stream, indent+1, allowance, context, level)
-		io.WriteString(stream, "\n"+strings.Repeat(" ", indent)+")")
+// pprintFunc prints a function as its name and signature, such as
+// main.handler func(string, int) error.
+func (pp *PrettyPrinter) pprintFunc(object any, context Context, level int) *Doc {
+	value := reflect.ValueOf(object)
+	if value.IsNil() {
+		return Text(pp.nilPointer(object))
 	}
+	pp.readable = false
+	return Text(paint(pp.theme.Type, funcSignature(value)))
 }
 
-func (pp PrettyPrinter) formatStructItems(items []StructField, stream io.Writer, indent, allowance int, context Context, level int) {
-	delimnl := ",\n"
-	delim := strings.Repeat(" ", indent+1)
-	lastIndex := len(items) - 1
-	for i, item := range items {
-		last := i == lastIndex
+// pprintChan prints a channel as its type, which includes the direction,
+// and address followed by its length and capacity, as in
+// (chan int=0xc000010000)(len=1, cap=4).
+func (pp *PrettyPrinter) pprintChan(object any, context Context, level int) *Doc {
+	value := reflect.ValueOf(object)
+	if value.IsNil() {
+		return Text(pp.nilPointer(object))
+	}
+	pp.readable = false
+	prefix := strings.TrimSuffix(pp.pointerPrefix(object), "&")
+	return Text(prefix + fmt.Sprintf("(len=%d, cap=%d)", value.Len(), value.Cap()))
+}
 
-		io.WriteString(stream, delim)
-		io.WriteString(stream, item.Name)
-		io.WriteString(stream, "=")
+// pprintUnsafePointer prints an unsafe.Pointer as a conversion of its
+// address.
+func (pp *PrettyPrinter) pprintUnsafePointer(object any, context Context, level int) *Doc {
+	value := reflect.ValueOf(object)
+	if value.IsNil() {
+		return Text(pp.nilPointer(object))
+	}
+	pp.readable = false
+	address := paint(pp.theme.Address, fmt.Sprintf("%#x", value.Pointer()))
+	if pp.hideAddresses {
+		address = paint(pp.theme.Marker, "<address>")
+	}
+	return Text("(" + paint(pp.theme.Type, value.Type().String()) + ")(" + address + ")")
+}
 
-		// if idInContext(id(item.Entry), context) {
-		if context.Contains(id(item.Entry)) {
-			io.WriteString(stream, "...")
-		} else {
-			pp.format(item.Entry, stream, indent+len(item.Name)+2, allowance, context, level)
-		}
+func (pp *PrettyPrinter) pprintMap(object any, context Context, level int) *Doc {
+	value := reflect.ValueOf(object)
+	if value.Len() == 0 {
+		return Text("{}")
+	}
 
-		if !last {
-			io.WriteString(stream, delimnl)
+	items := []*Doc{}
+	for _, item := range pp.mapItems(value) {
+		// Keys always stay on one line
+		key := pp.paintKey(flatString(pp.build(item.Key, context, level+1)))
+		pp.pushPath("[" + stripANSI(key) + "]")
+		var entry *Doc
+		if pp.redaction.key(reflect.ValueOf(item.Key)) {
+			entry = pp.redacted(item.Entry)
+		} else {
+			entry = pp.build(item.Entry, context, level+1)
 		}
+		items = append(items, Concat(Text(key), Text(": "), entry))
+		pp.popPath()
 	}
+	return pp.bracketed(Text("{"), Text("}"), items, false)
 }
 
-func (pp PrettyPrinter) pprintString(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	// io.WriteString()
-	if str, ok := object.(string); ok {
-		if len(str) == 0 {
-			// Assuming repr is implemented to return the string's representation
-			io.WriteString(stream, repr(object))
-			return
-		}
-		chunks := []string{}
-		lines := strings.Split(str, "\n")
-		if level == 1 {
-			indent += 1
-			allowance += 1
-		}
-		maxWidth1 := pp.width - indent
-		maxWidth := maxWidth1 // You don't need a pointer here, just use the value directly
-
-		rep := ""
-		for i, line := range lines {
-			rep = repr(object) // Ensure repr is properly implemented in Go
-			if i == len(lines)-1 {
-				maxWidth1 -= allowance
-			}
-			if len(rep) <= maxWidth1 {
-				chunks = append(chunks, rep)
-			} else {
-				re := regexp.MustCompile(`\S*\s*`)
-				parts := re.FindAllString(line, -1)
-				if len(parts) == 0 {
-					fmt.Println("No parts found")
-					return
-				}
-				if parts[len(parts)-1] != "" {
-					fmt.Println("Last part should be empty")
-					return
-				}
-				parts = parts[:len(parts)-1]
-				maxWidth2 := maxWidth
-				current := ""
-				for j, part := range parts {
-					candidate := current + part
-					if j == len(parts)-1 && i == len(lines)-1 {
-						maxWidth2 -= allowance
-					}
-					if len(repr(candidate)) > maxWidth2 {
-						if len(current) > 0 {
-							chunks = append(chunks, repr(current))
-						}
-						current = part
-					} else {
-						current = candidate
-					}
-				}
-				if len(current) > 0 {
-					chunks = append(chunks, repr(current))
-				}
-			}
-		}
-		if len(chunks) == 1 {
-			io.WriteString(stream, rep)
-			return
-		}
-		if level == 1 {
-			io.WriteString(stream, "(")
-		}
-		for i, rep := range chunks {
-			if i > 0 {
-				io.WriteString(stream, "\n"+strings.Repeat(" ", indent))
-			}
-			io.WriteString(stream, rep)
-		}
-		if level == 1 {
-			io.WriteString(stream, ")")
-		}
+// mapItems collects the entries of a map of any type, sorted by key when
+// sortMaps is set.
+func (pp *PrettyPrinter) mapItems(value reflect.Value) []MappingItem {
+	entries := mapEntries(value, pp.sortMaps)
+	items := make([]MappingItem, len(entries))
+	for i, entry := range entries {
+		items[i] = MappingItem{Key: entry.key.Interface(), Entry: entry.value.Interface()}
 	}
+	return items
 }
 
-func (pp PrettyPrinter) pprintBytes(object any, stream io.Writer, indent, allowance int, context Context, level int) {
-	if data, ok := object.([]byte); ok {
-		if len(data) <= 4 {
-			io.WriteString(stream, fmt.Sprintf("%x", data))
-			return
-		}
+func (pp *PrettyPrinter) pprintSlice(object any, context Context, level int) *Doc {
+	// Wrap []byte
+	if _, ok := object.([]byte); ok {
+		return pp.pprintBytes(object, context, level)
+	}
+	return pp.sliceDoc(reflect.ValueOf(object), context, level)
+}
 
-		parens := level == 1
-		if parens {
-			indent++
-			allowance++
-			io.WriteString(stream, "(")
-		}
+// sliceDoc lays out the elements of any slice or array type. A single
+// element is written as (x,) on one line, or as [x] inside a conversion.
+func (pp *PrettyPrinter) sliceDoc(value reflect.Value, context Context, level int) *Doc {
+	if value.Len() == 0 {
+		return Text("[]")
+	}
 
-		delim := ""
-		wrapped := wrapBytesRepr(data, pp.width-indent, allowance) // Assuming 80 as the default width.
-		for _, line := range wrapped {
-			io.WriteString(stream, delim)
-			// io.WriteString(stream, strings.Repeat(" ", indent))
-			io.WriteString(stream, line)
-			if delim == "" {
-				delim = "\n"
-			}
-		}
+	converted := pp.converted == level+1
+	items := make([]*Doc, value.Len())
+	for i, item := range sliceItems(value) {
+		pp.pushPath(fmt.Sprintf("[%d]", i))
+		items[i] = pp.build(item, context, level+1)
+		pp.popPath()
+	}
 
-		if parens {
-			io.WriteString(stream, ")")
-		}
+	open, close := Text("["), Text("]")
+	if len(items) == 1 && !converted {
+		open, close = IfBreak(open, Text("(")), IfBreak(close, Text(",)"))
 	}
+	return pp.bracketed(open, close, items, pp.compact)
 }
 
-// repr simulates the Python's repr function that returns a string representation of the object.
-func (pp PrettyPrinter) repr(object any, context Context, level int) string {
-	repr, readable, recursive := pp.Format(object, copyContext(context), pp.depth, level)
-	if !readable {
-		pp.readable = false
+// sliceItems collects the elements of a slice or array of any type.
+func sliceItems(value reflect.Value) []any {
+	items := make([]any, value.Len())
+	for i := range items {
+		items[i] = value.Index(i).Interface()
 	}
-	if recursive {
-		recursive = true
-	}
-	return repr
+	return items
 }
 
-func (pp PrettyPrinter) Format(object any, context Context, maxLevels, level int) (string, bool, bool) {
-	return pp.safeRepr(object, context, maxLevels, level)
+func (pp *PrettyPrinter) pprintStruct(object any, context Context, level int) *Doc {
+	return Align(pp.structDoc(reflect.ValueOf(object), context, level))
 }
 
-func (pp PrettyPrinter) safeRepr(object any, context Context, maxLevels, level int) (string, bool, bool) {
+// structDoc lays out a struct as a composite literal, with its fields on
+// their own lines, two columns per indent level deeper, when it does not fit.
+func (pp *PrettyPrinter) structDoc(value reflect.Value, context Context, level int) *Doc {
+	name := paint(pp.theme.Type, value.Type().Name())
+	if value.NumField() == 0 {
+		return Text(name + "{}")
+	}
 
-	if object == nil {
-		return repr(object), false, false
+	tags := structTags(value.Type())
+	fields := []*Doc{}
+	for i, item := range structItems(value, pp.unexported) {
+		tag := tags[i]
+		if tag.skip || tag.omitEmpty && value.Field(i).IsZero() {
+			continue
+		}
+
+		pp.pushPath("." + item.Name)
+		label := item.Name
+		if tag.name != "" {
+			// Renamed fields do not read back
+			pp.readable = false
+			label = tag.name
+		}
+		label = pp.paintKey(label)
+		if item.Private && pp.unexported {
+			// Private fields cannot be set from a printed literal
+			pp.readable = false
+			label = paint(pp.theme.Marker, privateMarker) + label
+		}
+
+		var entry *Doc
+		if tag.hint == "secret" || pp.redaction.field(item.Name) || pp.redaction.field(tag.name) {
+			entry = pp.redacted(item.Entry)
+		} else if rep, readable, ok := pp.hinted(tag.hint, item.Entry); ok {
+			pp.readable = pp.readable && readable
+			entry = Text(rep)
+		} else if typ := pp.dynamicType(value.Type().Field(i).Type, item.Entry, context); typ != "" {
+			// Conversions nest, in slices of structs with interface fields
+			converted := pp.converted
+			pp.converted = level + 2
+			entry = Concat(Text(paint(pp.theme.Type, typ)+"("), pp.build(item.Entry, context, level+1), Text(")"))
+			pp.converted = converted
+		} else {
+			entry = pp.build(item.Entry, context, level+1)
+		}
+		fields = append(fields, Concat(Text(label), Text(": "), entry))
+		pp.popPath()
+	}
+	if len(fields) == 0 {
+		return Text(name + "{}")
 	}
 
-	if object, ok := object.(InaccessibleField); ok {
-		return object.String(), false, false
+	sep := Concat(Text(","), Line())
+	body := Join(sep, fields)
+	if pp.compact {
+		body = Fill(sep, fields)
 	}
+	return Group(Concat(
+		Text(name+"{"),
+		Nest(2*pp.indentPerLevel, Concat(SoftLine(), body, IfBreak(Text(","), nil))),
+		SoftLine(),
+		Text("}"),
+	))
+}
 
-	// Get the type of the object
-	typ := reflect.TypeOf(object)
+// redacted returns the marker that replaces a sensitive value.
+func (pp *PrettyPrinter) redacted(object any) *Doc {
+	pp.readable = false
+	return Text(paint(pp.theme.Marker, pp.redaction.marker(reflect.ValueOf(object))))
+}
 
-	// Check if the object is one of the basic scalar types (e.g., int, float)
-	for _, element := range builtinScalars {
-		if element == typ {
-			return repr(object), true, false
-		}
+// privateMarker precedes the names of unexported fields.
+const privateMarker = "~"
+
+// structItems collects the fields of a struct. Unexported fields are read
+// through an addressable copy when private is set, and replaced with
+// InaccessibleField otherwise.
+func structItems(value reflect.Value, private bool) []StructField {
+	typ := value.Type()
+	if private && !value.CanAddr() {
+		addressable := reflect.New(typ).Elem()
+		addressable.Set(value)
+		value = addressable
 	}
 
-	// Handle integer types (int, int32, int64, etc.)
-	if typ.Kind() == reflect.Int {
-		if pp.underscoreNumbers {
-			// Format with underscores for readability
-			return formatWithUnderscores(object.(int)), true, false
+	items := make([]StructField, value.NumField())
+	for i := range items {
+		field := value.Field(i)
+		items[i].Name = typ.Field(i).Name
+		items[i].Private = !typ.Field(i).IsExported()
+		if field.IsValid() && field.CanInterface() {
+			items[i].Entry = field.Interface() // Access the field as interface{}
+		} else if private {
+			items[i].Entry = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface()
+		} else {
+			items[i].Entry = InaccessibleField{Name: items[i].Name, Reason: "unexported"}
 		}
-		return fmt.Sprintf("%d", object), true, false
 	}
+	return items
+}
 
-	// Handle maps (map[any]any)
-	if typ.Kind() == reflect.Map {
-		value := reflect.ValueOf(object)
-		if value.Len() == 0 {
-			return "{}", true, false
-		}
-
-		// Get the unique ID of the object
-		objectId := id(object)
+// stringParts splits a line into words, each keeping its trailing whitespace.
+var stringParts = regexp.MustCompile(`\S*\s*`)
 
-		// Recursion limit handling
-		if maxLevels > 0 && level >= maxLevels {
-			// return "{...}", false, idInContext(objectId, context)
-			return "{...}", false, context.Contains(objectId)
-		}
+func (pp *PrettyPrinter) pprintString(object any, context Context, level int) *Doc {
+	value := reflect.ValueOf(object)
+	rep, _, _ := pp.scalarRepr(value)
+	if value.Len() == 0 {
+		return Text(rep)
+	}
+	return wrapped(rep, func(column, trailing int) *Doc {
+		return pp.wrapString(value, column, trailing, level)
+	})
+}
 
-		// Prevent infinite recursion
-		// if idInContext(objectId, context) {
-		if context.Contains(objectId) {
-			return "{...}", false, true
-		}
+// wrapString splits a string that does not fit at column into literals
+// joined with "+", breaking lines at embedded newlines and word boundaries.
+func (pp *PrettyPrinter) wrapString(value reflect.Value, indent, allowance, level int) *Doc {
+	str := value.String()
 
-		// Track this object in the context
-		context[objectId] = 1
+	// Named string types keep their name as a conversion
+	open := ""
+	if level == 0 {
+		open = "("
+	}
+	named := pp.namedTypes && isNamed(value.Type())
+	if named {
+		open = paint(pp.theme.Type, value.Type().Name()) + "("
+	}
+	if open != "" {
+		indent += displayWidth(open)
+		allowance++
+	}
 
-		readable := true
-		recursive := false
-		components := []string{}
+	// Every chunk but the last is followed by " +"
+	maxWidth := pp.width - indent - 2
+	lastWidth := pp.width - indent - allowance
 
-		// Increment recursion level
-		level += 1
+	// Split on embedded newlines first, keeping them with their line
+	lines := strings.SplitAfter(str, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
 
-		// Get map keys and sort them if necessary
-		keys := make([]any, 0, value.Len())
-		for _, k := range value.MapKeys() {
-			keys = append(keys, k.Interface()) // Convert reflect.Value to actual value
+	chunks := []*Doc{}
+	for i, line := range lines {
+		lastLine := i == len(lines)-1
+		width := maxWidth
+		if lastLine {
+			width = lastWidth
 		}
-
-		if pp.sortMaps {
-			sort.Slice(keys, func(i, j int) bool {
-				return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j])
-			})
+		if rep := strconv.Quote(line); len(rep) <= width {
+			chunks = append(chunks, Text(paint(pp.theme.String, rep)))
+			continue
 		}
 
-		// Iterate over sorted keys and process key-value pairs
-		for _, k := range keys {
-			kRepr, kReadable, kRecur := pp.Format(k, context, maxLevels, level)
-			vRepr, vReadable, vRecur := pp.Format(value.MapIndex(reflect.ValueOf(k)).Interface(), context, maxLevels, level)
-			components = append(components, fmt.Sprintf("%s: %s", kRepr, vRepr))
-			readable = readable && kReadable && vReadable
-			if kRecur || vRecur {
-				recursive = true
+		// Wrap the line at word boundaries
+		parts := stringParts.FindAllString(line, -1)
+		current := ""
+		for j, part := range parts {
+			if j == len(parts)-1 && lastLine {
+				width = lastWidth
+			} else {
+				width = maxWidth
+			}
+			candidate := current + part
+			if len(strconv.Quote(candidate)) > width && len(current) > 0 {
+				chunks = append(chunks, Text(paint(pp.theme.String, strconv.Quote(current))))
+				current = part
+			} else {
+				current = candidate
 			}
 		}
+		if len(current) > 0 {
+			chunks = append(chunks, Text(paint(pp.theme.String, strconv.Quote(current))))
+		}
+	}
 
-		// Cleanup context after processing this object
-		delete(context, objectId)
-
-		// Return the formatted map representation
-		return fmt.Sprintf("{%s}", strings.Join(components, ", ")), readable, recursive
+	if len(chunks) == 1 && !named {
+		return chunks[0]
+	}
+	body := Align(Join(Concat(Text(" +"), Line()), chunks))
+	if open == "" {
+		return body
 	}
+	return Concat(Text(open), body, Text(")"))
+}
 
-	// Handle slices (which corresponds to Python's list and tuple types)
-	if typ.Kind() == reflect.Slice {
-		value := reflect.ValueOf(object)
-		if value.Len() == 0 {
-			return "[]", true, false // Empty slice
-		}
+func (pp *PrettyPrinter) pprintBytes(object any, context Context, level int) *Doc {
+	data := object.([]byte)
+	flat := flatString(pp.sliceDoc(reflect.ValueOf(object), context, level))
+	return wrapped(flat, func(column, trailing int) *Doc {
+		return pp.wrapBytes(data, column, trailing, level)
+	})
+}
 
-		// Determine format based on length of slice
-		format := "[%s]"
-		if value.Len() == 1 {
-			format = "(%s,)" // Single-element tuple format
-		}
+// wrapBytes writes bytes that do not fit at column as lines of hex digits.
+func (pp *PrettyPrinter) wrapBytes(data []byte, indent, allowance, level int) *Doc {
+	if len(data) <= 4 {
+		return Text(paint(pp.theme.Number, fmt.Sprintf("%x", data)))
+	}
 
-		objectId := id(object)
+	parens := level == 0
+	if parens {
+		indent++
+		allowance++
+	}
 
-		// Recursion limit handling
-		if maxLevels > 0 && level >= maxLevels {
-			// return fmt.Sprintf(format, "..."), false, idInContext(objectId, context)
-			return fmt.Sprintf(format, "..."), false, context.Contains(objectId)
-		}
+	lines := []*Doc{}
+	for _, line := range wrapBytesRepr(data, pp.width-indent, allowance) {
+		lines = append(lines, Text(paint(pp.theme.Number, line)))
+	}
+	body := Join(rawLine(), lines)
+	if !parens {
+		return body
+	}
+	return Concat(Text("("), body, Text(")"))
+}
 
-		// Prevent infinite recursion
-		// if idInContext(objectId, context) {
-		if context.Contains(objectId) {
-			return recursion(object), false, true
-		}
+// Format returns the single-line representation of object, and whether it
+// is readable and recursive.
+func (pp *PrettyPrinter) Format(object any, context Context, maxLevels, level int) (string, bool, bool) {
+	call := *pp
+	call.depth = maxLevels
+	call.readable = true
+	call.recursive = false
+	if context == nil {
+		context = make(Context)
+	}
+	rep := flatString(call.build(object, context, level))
+	return rep, call.readable, call.recursive
+}
 
-		// Track object in the context to handle recursion
-		context[objectId] = 1
+// pointerPrefix returns the (*T=0xc000010000)& that precedes the value a
+// pointer points to.
+func (pp *PrettyPrinter) pointerPrefix(object any) string {
+	typ := paint(pp.theme.Type, fmt.Sprintf("%T", object))
+	if pp.hideAddresses {
+		return "(" + typ + ")&"
+	}
+	address := paint(pp.theme.Address, fmt.Sprintf("%p", object))
+	return "(" + typ + "=" + address + ")&"
+}
 
-		readable := true
-		recursive := false
-		components := []string{}
-		level += 1
+// nilPointer returns the (*T)(nil) a nil pointer is printed as.
+func (pp *PrettyPrinter) nilPointer(object any) string {
+	return "(" + paint(pp.theme.Type, fmt.Sprintf("%T", object)) + ")(" + paint(pp.theme.Bool, "nil") + ")"
+}
 
-		// Process each element in the slice
-		for i := 0; i < value.Len(); i++ {
-			elem := value.Index(i).Interface()
-			elemRepr, elemReadable, elemRecur := pp.Format(elem, context, maxLevels, level)
-			components = append(components, elemRepr)
+// dynamicType returns the type that a value held in a field of interface
+// type is printed as a conversion to, or "" when its literal already names
+// the type or has it by default, as 1 is an int.
+func (pp *PrettyPrinter) dynamicType(field reflect.Type, object any, context Context) string {
+	if field.Kind() != reflect.Interface || object == nil {
+		return ""
+	}
+	value := reflect.ValueOf(object)
+	switch value.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return ""
+	}
+	if _, isCustom := pp.lookupPrinter(value); isCustom || pp.redaction.value(value) {
+		return ""
+	}
 
-			// Update readability and recursion flags
-			if !elemReadable {
-				readable = false
-			}
-			if elemRecur {
-				recursive = true
-			}
+	// Cycle markers and shared labels stand in for the value
+	if objectId := id(object); objectId != 0 && context.Contains(objectId) {
+		return ""
+	}
+	if key, ok := sharedKeyOf(value); ok && pp.shared != nil {
+		if _, shared := pp.shared[key]; shared {
+			return ""
 		}
+	}
+
+	typ := value.Type()
+	switch typ {
+	case reflect.TypeOf(false), reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(0i), reflect.TypeOf(""):
+		return ""
+	}
+	if pp.namedTypes && isNamed(typ) && (value.Kind() <= reflect.Complex128 || value.Kind() == reflect.String) {
+		// Already printed as a conversion
+		return ""
+	}
+
+	// Parse reads the value back without its type
+	pp.readable = false
+	return typ.String()
+}
 
-		// Clean up context after processing
-		delete(context, objectId)
+// isContainer reports whether object is replaced by a placeholder past the
+// depth limit.
+func isContainer(object any) bool {
+	switch reflect.TypeOf(object).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	}
+	return false
+}
 
-		// Return the formatted string for the slice
-		return fmt.Sprintf(format, strings.Join(components, ", ")), readable, recursive
+// elided returns the placeholder that replaces a container nested deeper
+// than the depth limit, including how many elements were left out.
+func elided(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Map:
+		return "{..." + elidedCount(value) + "}"
+	case reflect.Slice, reflect.Array:
+		return "[..." + elidedCount(value) + "]"
+	case reflect.Struct:
+		return value.Type().Name() + "{..." + elidedCount(value) + "}"
 	}
+	return "..."
+}
 
-	rep := repr(object)
+// elidedCount describes how many elements of a container were left out.
+func elidedCount(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Map:
+		return countOf(size(value), "entry", "entries")
+	case reflect.Slice, reflect.Array:
+		return countOf(size(value), "item", "items")
+	case reflect.Struct:
+		return countOf(size(value), "field", "fields")
+	}
+	return ""
+}
 
-	return rep, true, false
+// size returns the number of entries, items or fields of a container.
+func size(value reflect.Value) int {
+	if value.Kind() == reflect.Struct {
+		return value.NumField()
+	}
+	return value.Len()
+}
+
+func countOf(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf(" %d %s", n, singular)
+	}
+	return fmt.Sprintf(" %d %s", n, plural)
 }
@@ -0,0 +1,89 @@
+package pprint
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redaction is a policy for values that must not appear in the output, such
+// as passwords, tokens and keys. Values of fields tagged `pprint:",secret"`,
+// of fields and string map keys whose names match a pattern, and of the
+// listed types are replaced with <redacted>, by both the PrettyPrinter and
+// the Marshalizer.
+type Redaction struct {
+	Fields *regexp.Regexp // struct field names, such as (?i)password|secret|token
+	Keys   *regexp.Regexp // string map keys
+	Types  []reflect.Type // exact types, or interfaces the values implement
+
+	Length bool // show the length of the value, as in <redacted len=7>
+	Hash   bool // show a prefix of the SHA-256 of the value, as in <redacted sha256=f52fbd32>
+}
+
+// redactedMarker replaces sensitive values.
+const redactedMarker = "<redacted>"
+
+// field reports whether the values of struct fields named name are redacted.
+func (r *Redaction) field(name string) bool {
+	return r != nil && r.Fields != nil && r.Fields.MatchString(name)
+}
+
+// key reports whether the values of the map entries with key are redacted.
+func (r *Redaction) key(key reflect.Value) bool {
+	return r != nil && r.Keys != nil && key.Kind() == reflect.String && r.Keys.MatchString(key.String())
+}
+
+// value reports whether value is redacted for its type.
+func (r *Redaction) value(value reflect.Value) bool {
+	if r == nil || !value.IsValid() {
+		return false
+	}
+	typ := value.Type()
+	for _, redacted := range r.Types {
+		if typ == redacted || redacted.Kind() == reflect.Interface && typ.Implements(redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// inaccessibleType is the type of the placeholder of an unexported field,
+// which has nothing to describe.
+var inaccessibleType = reflect.TypeOf(InaccessibleField{})
+
+// marker returns the text that replaces value.
+func (r *Redaction) marker(value reflect.Value) string {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if r == nil || !r.Length && !r.Hash || !value.IsValid() || value.Type() == inaccessibleType {
+		return redactedMarker
+	}
+
+	var data []byte
+	switch {
+	case value.Kind() == reflect.String:
+		data = []byte(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		data = value.Bytes()
+	default:
+		// fmt reads values of unexported fields that Interface refuses
+		data = []byte(fmt.Sprint(value))
+	}
+
+	details := []string{}
+	if r.Length {
+		length := len(data)
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+			length = value.Len()
+		}
+		details = append(details, fmt.Sprintf("len=%d", length))
+	}
+	if r.Hash {
+		details = append(details, fmt.Sprintf("sha256=%x", sha256.Sum256(data))[:len("sha256=")+8])
+	}
+	return "<redacted " + strings.Join(details, " ") + ">"
+}
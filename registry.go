@@ -0,0 +1,142 @@
+package pprint
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Printer lays out object by writing to s, like Formatter.PrettyFormat.
+type Printer func(s *State, object any)
+
+type interfacePrinter struct {
+	iface   reflect.Type
+	printer Printer
+}
+
+// Registry holds the printers used in place of the built-in layout for exact
+// types, for types implementing an interface and for kinds. When several
+// entries match a value, type entries win over interface entries, which are
+// tried in registration order, then types implementing Formatter, and kind
+// entries come last. Each tier is looked up in the registry and then in its
+// parent, so a registry overrides the one it was created from.
+//
+// Registries are not safe for concurrent modification; add printers before
+// printing, for instance in an init function.
+type Registry struct {
+	parent     *Registry
+	types      map[reflect.Type]Printer
+	interfaces []interfacePrinter
+	kinds      map[reflect.Kind]Printer
+}
+
+// DefaultRegistry is the registry that printers created with New and
+// NewPrettyPrinter inherit from.
+var DefaultRegistry = NewRegistry(nil)
+
+// NewRegistry returns an empty registry layered on parent, which may be nil.
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{
+		parent: parent,
+		types:  make(map[reflect.Type]Printer),
+		kinds:  make(map[reflect.Kind]Printer),
+	}
+}
+
+// AddType prints values of exactly type typ with printer.
+func (r *Registry) AddType(typ reflect.Type, printer Printer) {
+	if _, exists := r.types[typ]; exists {
+		panic(fmt.Sprintf("type %s already registered", typ))
+	}
+	r.types[typ] = printer
+}
+
+// RemoveType removes the printer for type typ.
+func (r *Registry) RemoveType(typ reflect.Type) {
+	if _, exists := r.types[typ]; !exists {
+		panic(fmt.Sprintf("type %s not in registry", typ))
+	}
+	delete(r.types, typ)
+}
+
+// AddInterface prints values whose type implements the interface type iface
+// with printer. Nil pointers are printed as nil rather than passed to it.
+func (r *Registry) AddInterface(iface reflect.Type, printer Printer) {
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("%s is not an interface", iface))
+	}
+	if r.interfaceIndex(iface) >= 0 {
+		panic(fmt.Sprintf("interface %s already registered", iface))
+	}
+	r.interfaces = append(r.interfaces, interfacePrinter{iface: iface, printer: printer})
+}
+
+// RemoveInterface removes the printer for the interface type iface.
+func (r *Registry) RemoveInterface(iface reflect.Type) {
+	i := r.interfaceIndex(iface)
+	if i < 0 {
+		panic(fmt.Sprintf("interface %s not in registry", iface))
+	}
+	r.interfaces = append(r.interfaces[:i:i], r.interfaces[i+1:]...)
+}
+
+// AddKind prints values of kind with printer, unless an entry for their type
+// or an interface they implement matches first.
+func (r *Registry) AddKind(kind reflect.Kind, printer Printer) {
+	if _, exists := r.kinds[kind]; exists {
+		panic(fmt.Sprintf("kind %s already registered", kind))
+	}
+	r.kinds[kind] = printer
+}
+
+// RemoveKind removes the printer for kind.
+func (r *Registry) RemoveKind(kind reflect.Kind) {
+	if _, exists := r.kinds[kind]; !exists {
+		panic(fmt.Sprintf("kind %s not in registry", kind))
+	}
+	delete(r.kinds, kind)
+}
+
+func (r *Registry) interfaceIndex(iface reflect.Type) int {
+	for i, entry := range r.interfaces {
+		if entry.iface == iface {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookup returns the printer for value, if any.
+func (r *Registry) lookup(value reflect.Value) (Printer, bool) {
+	typ := value.Type()
+	for layer := r; layer != nil; layer = layer.parent {
+		if printer, exists := layer.types[typ]; exists {
+			return printer, true
+		}
+	}
+
+	// Methods of nil pointers are not called
+	if value.Kind() != reflect.Pointer || !value.IsNil() {
+		for layer := r; layer != nil; layer = layer.parent {
+			for _, entry := range layer.interfaces {
+				if typ.Implements(entry.iface) {
+					return entry.printer, true
+				}
+			}
+		}
+		if _, ok := value.Interface().(Formatter); ok {
+			return formatWith, true
+		}
+	}
+
+	for layer := r; layer != nil; layer = layer.parent {
+		if printer, exists := layer.kinds[value.Kind()]; exists {
+			return printer, true
+		}
+	}
+	return nil, false
+}
+
+// formatWith prints values that implement Formatter.
+func formatWith(s *State, object any) {
+	object.(Formatter).PrettyFormat(s)
+}
@@ -1,47 +1,233 @@
 package pprint
 
-// type safeKey struct {
-// 	obj any
-// }
-
-// func newSafeKey(obj any) safeKey {
-// 	return safeKey{obj: obj}
-// }
-
-// // lessThan compares two safeKey objects.
-// func (sk safeKey) lessThan(other safeKey) bool {
-// 	// Attempt direct comparison if possible
-// 	if reflect.TypeOf(sk.obj) == reflect.TypeOf(other.obj) {
-// 		switch v := sk.obj.(type) {
-// 		case int:
-// 			return v < other.obj.(int)
-// 		case string:
-// 			return v < other.obj.(string)
-// 		// Add cases for other directly comparable types as needed
-// 		default:
-// 			// If types are the same but not directly comparable, fall back
-// 			return sk.fallbackCompare(other)
-// 		}
-// 	}
-
-// 	// Fallback for unorderable or mismatched types
-// 	return sk.fallbackCompare(other)
-// }
-
-// // fallbackCompare handles fallback comparison logic.
-// func (sk safeKey) fallbackCompare(other safeKey) bool {
-// 	// Compare types first
-// 	typeStr1 := fmt.Sprintf("%T", sk.obj)
-// 	typeStr2 := fmt.Sprintf("%T", other.obj)
-// 	if typeStr1 != typeStr2 {
-// 		return typeStr1 < typeStr2
-// 	}
-
-// 	// If types are the same, compare memory addresses
-// 	return uintptr(unsafe.Pointer(&sk.obj)) < uintptr(unsafe.Pointer(&other.obj))
-// }
-
-// // Helper function for comparing 2-slices
-// func safeTuple(t []any) (safeKey, safeKey) {
-// 	return safeKey{obj: t[0]}, safeKey{obj: t[1]}
-// }
+import (
+	"cmp"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// safeKey wraps a map key so that keys of any type, including a mix of types
+// in a map[any]any, can be sorted in a deterministic total order.
+type safeKey struct {
+	value reflect.Value
+}
+
+func newSafeKey(value reflect.Value) safeKey {
+	return safeKey{value: value}
+}
+
+// lessThan compares two safeKey objects.
+func (sk safeKey) lessThan(other safeKey) bool {
+	return compareValues(sk.value, other.value) < 0
+}
+
+// mapEntry is a key of a map with its value, read together so that keys
+// that are not equal to themselves, such as NaN, keep their values.
+type mapEntry struct {
+	key, value reflect.Value
+}
+
+// mapEntries returns the entries of the map value, sorted by key in the
+// order defined by compareValues when sorted is set.
+func mapEntries(value reflect.Value, sorted bool) []mapEntry {
+	entries := make([]mapEntry, 0, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		entries = append(entries, mapEntry{key: iter.Key(), value: iter.Value()})
+	}
+	if sorted {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return newSafeKey(entries[i].key).lessThan(newSafeKey(entries[j].key))
+		})
+	}
+	return entries
+}
+
+// keyClass groups kinds whose values can be compared with each other directly.
+type keyClass int
+
+const (
+	classNil keyClass = iota
+	classBool
+	classNumber
+	classString
+	classOther
+)
+
+func classOf(value reflect.Value) keyClass {
+	if !value.IsValid() {
+		return classNil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		return classBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return classNumber
+	case reflect.String:
+		return classString
+	}
+	return classOther
+}
+
+// compareValues returns -1, 0 or +1 depending on whether a sorts before,
+// together with or after b. nil comes first, then bools, numbers, strings and
+// other values. Numbers are ordered by value, strings lexically, false before
+// true, structs and arrays element by element and pointers by address. Other
+// values of different types are ordered by type name.
+func compareValues(a, b reflect.Value) int {
+	a, b = unwrapInterface(a), unwrapInterface(b)
+
+	classA, classB := classOf(a), classOf(b)
+	if classA != classB {
+		return cmp.Compare(classA, classB)
+	}
+
+	if classA != classOther && classA != classNil {
+		var c int
+		switch classA {
+		case classBool:
+			c = compareBools(a.Bool(), b.Bool())
+		case classNumber:
+			c = compareNumbers(a, b)
+		case classString:
+			c = strings.Compare(a.String(), b.String())
+		}
+		if c != 0 {
+			return c
+		}
+		// Equal values of different types, e.g. int(1) and int64(1)
+		return compareTypes(a.Type(), b.Type())
+	}
+
+	if !a.IsValid() {
+		return 0
+	}
+	if a.Type() != b.Type() {
+		return compareTypes(a.Type(), b.Type())
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if c := compareValues(a.Field(i), b.Field(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if c := compareValues(a.Index(i), b.Index(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer, reflect.Map, reflect.Slice, reflect.Func:
+		return cmp.Compare(a.Pointer(), b.Pointer())
+	}
+	return 0
+}
+
+func unwrapInterface(value reflect.Value) reflect.Value {
+	for value.IsValid() && value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func compareTypes(a, b reflect.Type) int {
+	if c := strings.Compare(a.String(), b.String()); c != 0 {
+		return c
+	}
+	return strings.Compare(a.PkgPath(), b.PkgPath())
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	}
+	return 1
+}
+
+func compareNumbers(a, b reflect.Value) int {
+	switch {
+	case isComplex(a) || isComplex(b):
+		ca, cb := complexOf(a), complexOf(b)
+		if c := compareFloats(real(ca), real(cb)); c != 0 {
+			return c
+		}
+		return compareFloats(imag(ca), imag(cb))
+	case isFloat(a) || isFloat(b):
+		return compareFloats(floatOf(a), floatOf(b))
+	case isSigned(a) && isSigned(b):
+		return cmp.Compare(a.Int(), b.Int())
+	case !isSigned(a) && !isSigned(b):
+		return cmp.Compare(a.Uint(), b.Uint())
+	case isSigned(a):
+		if a.Int() < 0 {
+			return -1
+		}
+		return cmp.Compare(uint64(a.Int()), b.Uint())
+	default:
+		if b.Int() < 0 {
+			return 1
+		}
+		return cmp.Compare(a.Uint(), uint64(b.Int()))
+	}
+}
+
+// compareFloats orders NaN before every other number so that sorting stays
+// deterministic.
+func compareFloats(a, b float64) int {
+	switch aNaN, bNaN := math.IsNaN(a), math.IsNaN(b); {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return -1
+	case bNaN:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
+
+func isSigned(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isFloat(value reflect.Value) bool {
+	return value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64
+}
+
+func isComplex(value reflect.Value) bool {
+	return value.Kind() == reflect.Complex64 || value.Kind() == reflect.Complex128
+}
+
+func floatOf(value reflect.Value) float64 {
+	switch {
+	case isFloat(value):
+		return value.Float()
+	case isSigned(value):
+		return float64(value.Int())
+	}
+	return float64(value.Uint())
+}
+
+func complexOf(value reflect.Value) complex128 {
+	if isComplex(value) {
+		return value.Complex()
+	}
+	return complex(floatOf(value), 0)
+}
@@ -0,0 +1,61 @@
+package pprint
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// scalarRepr formats values of the basic kinds by kind, so that named types
+// such as type Status int are printed like their underlying type. ok is false
+// for values of other kinds.
+func (pp *PrettyPrinter) scalarRepr(value reflect.Value) (rep string, readable, ok bool) {
+	switch value.Kind() {
+	case reflect.Bool:
+		rep = strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rep = strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint8, reflect.Uintptr:
+		// Bytes and addresses read best in hex
+		rep = fmt.Sprintf("0x%x", value.Uint())
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		rep = strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		rep = strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits())
+	case reflect.Complex64:
+		rep = fmt.Sprint(complex64(value.Complex()))
+	case reflect.Complex128:
+		rep = fmt.Sprint(value.Complex())
+	case reflect.String:
+		rep = strconv.Quote(value.String())
+	default:
+		return "", false, false
+	}
+
+	readable = true
+	if pp.digitSeparator != "" {
+		if grouped, ok := formatNumber(value, pp.digitSeparator); ok {
+			// Only "_" reads back as a number
+			rep, readable = grouped, pp.digitSeparator == "_"
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		rep = paint(pp.theme.Bool, rep)
+	case reflect.String:
+		rep = paint(pp.theme.String, rep)
+	default:
+		rep = paint(pp.theme.Number, rep)
+	}
+	if pp.namedTypes && isNamed(value.Type()) {
+		rep = paint(pp.theme.Type, value.Type().Name()) + "(" + rep + ")"
+	}
+	return rep, readable, true
+}
+
+// isNamed reports whether typ is a declared type rather than a predeclared
+// one such as int or string.
+func isNamed(typ reflect.Type) bool {
+	return typ.Name() != "" && typ.PkgPath() != ""
+}
@@ -0,0 +1,149 @@
+package pprint
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// sharedKey identifies a pointer, map or slice. Slices of the same array
+// are told apart by their type and length.
+type sharedKey struct {
+	id  uintptr
+	typ reflect.Type
+	len int
+}
+
+func sharedKeyOf(value reflect.Value) (sharedKey, bool) {
+	// Distinct zero-size allocations may all have the same address
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.Type().Elem().Size() == 0 {
+			return sharedKey{}, false
+		}
+	case reflect.Slice:
+		if value.Cap() == 0 || value.Type().Elem().Size() == 0 {
+			return sharedKey{}, false
+		}
+	case reflect.Map:
+	default:
+		return sharedKey{}, false
+	}
+	objectId := valueId(value)
+	if objectId == 0 {
+		return sharedKey{}, false
+	}
+	key := sharedKey{id: objectId, typ: value.Type()}
+	if value.Kind() == reflect.Slice {
+		key.len = value.Len()
+	}
+	return key, true
+}
+
+// findShared walks the values reachable from object the way they are
+// printed and returns those reached more than once, mapped to 0 until they
+// are given a label.
+func (pp *PrettyPrinter) findShared(object any) map[sharedKey]int {
+	counts := make(map[sharedKey]int)
+	var walk func(value reflect.Value)
+	walk = func(value reflect.Value) {
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		if !value.IsValid() {
+			return
+		}
+		if key, ok := sharedKeyOf(value); ok {
+			counts[key]++
+			if counts[key] > 1 {
+				return
+			}
+		}
+
+		switch value.Kind() {
+		case reflect.Pointer:
+			walk(value.Elem())
+		case reflect.Map:
+			iter := value.MapRange()
+			for iter.Next() {
+				walk(iter.Key())
+				walk(iter.Value())
+			}
+		case reflect.Slice, reflect.Array:
+			if value.Type().Elem().Kind() == reflect.Uint8 {
+				return
+			}
+			for i := 0; i < value.Len(); i++ {
+				walk(value.Index(i))
+			}
+		case reflect.Struct:
+			tags := structTags(value.Type())
+			for i, item := range structItems(value, pp.unexported) {
+				if !tags[i].skip {
+					walk(reflect.ValueOf(item.Entry))
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(object))
+
+	shared := make(map[sharedKey]int)
+	for key, count := range counts {
+		if count > 1 {
+			shared[key] = 0
+		}
+	}
+	return shared
+}
+
+// buildShared prints a value reached more than once as &1 = value where it
+// is first printed and as *1 afterwards. ok is false for values that are not
+// shared, which are printed as usual.
+func (pp *PrettyPrinter) buildShared(object any, context Context, level int) (doc *Doc, ok bool) {
+	value := reflect.ValueOf(object)
+	key, ok := sharedKeyOf(value)
+	if !ok {
+		return nil, false
+	}
+	label, shared := pp.shared[key]
+	if !shared {
+		return nil, false
+	}
+
+	pp.readable = false
+	objectId := id(object)
+	if label > 0 {
+		if context.Contains(objectId) {
+			pp.markCycle()
+		}
+		return Text(paint(pp.theme.Marker, fmt.Sprintf("*%d", label))), true
+	}
+
+	// Pointers printed by the built-in printer are labeled in place of their
+	// prefix, unless the value they point to is left out past the depth limit
+	_, isCustom := pp.lookupPrinter(value)
+	elem := value.Kind() == reflect.Pointer && !isCustom && !pp.redaction.value(value)
+	target := value
+	if elem {
+		target = value.Elem()
+	}
+	if pp.depth > 0 && level >= pp.depth && isContainer(target.Interface()) && size(target) > 0 {
+		return nil, false
+	}
+
+	pp.labels++
+	pp.shared[key] = pp.labels
+	prefix := Text(paint(pp.theme.Marker, fmt.Sprintf("&%d", pp.labels)) + " = ")
+	if elem {
+		context[objectId] = len(pp.path)
+		defer delete(context, objectId)
+		doc = pp.build(target.Interface(), context, level)
+	} else {
+		doc = pp.buildValue(object, context, level)
+	}
+
+	// Struct fields are indented relative to the label
+	if doc.kind == docAlign {
+		return Align(Concat(prefix, doc.docs[0])), true
+	}
+	return Concat(prefix, doc), true
+}
@@ -0,0 +1,106 @@
+package pprint
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldTag holds the options of a struct field's pprint tag, such as
+// `pprint:"name=id,omitempty,hex"`:
+//
+//	"-"          the field is not printed
+//	"name=n"     the field is printed as n; a leading bare n works too
+//	"omitempty"  the field is not printed when it holds its zero value
+//	"hex"        integers and bytes are printed in hex
+//	"bytes"      integers are printed as a size such as 1.5 KiB
+//	"secret"     the value is replaced with <redacted>, see Redaction
+type fieldTag struct {
+	skip      bool
+	name      string
+	omitEmpty bool
+	hint      string
+}
+
+// fieldTags caches the parsed tags of the fields of each struct type.
+var fieldTags sync.Map // reflect.Type -> []fieldTag
+
+// structTags returns the parsed tags of the fields of the struct type typ.
+func structTags(typ reflect.Type) []fieldTag {
+	if tags, ok := fieldTags.Load(typ); ok {
+		return tags.([]fieldTag)
+	}
+	tags := make([]fieldTag, typ.NumField())
+	for i := range tags {
+		tags[i] = parseFieldTag(typ.Field(i).Tag.Get("pprint"))
+	}
+	actual, _ := fieldTags.LoadOrStore(typ, tags)
+	return actual.([]fieldTag)
+}
+
+func parseFieldTag(tag string) fieldTag {
+	if tag == "-" {
+		return fieldTag{skip: true}
+	}
+	parsed := fieldTag{}
+	for i, option := range strings.Split(tag, ",") {
+		switch {
+		case strings.HasPrefix(option, "name="):
+			parsed.name = strings.TrimPrefix(option, "name=")
+		case option == "omitempty":
+			parsed.omitEmpty = true
+		case option == "hex", option == "bytes", option == "secret":
+			parsed.hint = option
+		case i == 0:
+			parsed.name = option
+		}
+	}
+	return parsed
+}
+
+// hinted formats entry as the hint of its field tag asks. Only integers in
+// hex read back. ok is false when the hint does not apply to the kind of
+// entry, which is then printed as usual.
+func (pp *PrettyPrinter) hinted(hint string, entry any) (rep string, readable, ok bool) {
+	if _, inaccessible := entry.(InaccessibleField); inaccessible || entry == nil {
+		return "", false, false
+	}
+	value := reflect.ValueOf(entry)
+	switch hint {
+	case "hex":
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return paint(pp.theme.Number, fmt.Sprintf("%#x", value.Int())), true, true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return paint(pp.theme.Number, fmt.Sprintf("%#x", value.Uint())), true, true
+		case reflect.String:
+			return paint(pp.theme.Number, fmt.Sprintf("0x%x", value.String())), false, true
+		case reflect.Slice:
+			if value.Type().Elem().Kind() == reflect.Uint8 {
+				return paint(pp.theme.Number, fmt.Sprintf("0x%x", value.Bytes())), false, true
+			}
+		}
+	case "bytes":
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return paint(pp.theme.Number, byteSize(float64(value.Int()))), false, true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return paint(pp.theme.Number, byteSize(float64(value.Uint()))), false, true
+		}
+	}
+	return "", false, false
+}
+
+// byteSize formats a number of bytes with binary units and one decimal, such
+// as 1.5 KiB.
+func byteSize(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	unit := 0
+	for (n >= 1024 || n <= -1024) && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(n, 'f', 1, 64), ".0") + " " + units[unit]
+}
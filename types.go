@@ -1,14 +1,16 @@
 package pprint
 
 import (
-	"io"
 	"reflect"
 )
 
+// Context maps the id of each object being formatted to the length of its
+// access path, such as .Users[3], to report where a cycle closes.
 type Context map[uintptr]int
 type DispatchMap map[reflect.Kind]pprinter
 
-type pprinter func(pp PrettyPrinter, object any, stream io.Writer, indent, allowance int, context Context, level int)
+// pprinter renders an object of one kind into a document.
+type pprinter func(pp *PrettyPrinter, object any, context Context, level int) *Doc
 
 type MappingItem struct {
 	Key   any
@@ -16,11 +18,17 @@ type MappingItem struct {
 }
 
 type StructField struct {
-	Name  string
-	Entry any
+	Name    string
+	Entry   any
+	Private bool // unexported
 }
 
+// Contains reports whether the object with objectId is being formatted.
+// Values without an identity, reported by id as 0, are never contained.
 func (ctx Context) Contains(objectId uintptr) bool {
+	if objectId == 0 {
+		return false
+	}
 	_, exists := ctx[objectId]
 	return exists
 }